@@ -3,23 +3,45 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/lib/pq"
-
 	config "github.com/louiehdev/gatorcli/internal/config"
 
 	database "github.com/louiehdev/gatorcli/internal/database"
 
-	uuid "github.com/google/uuid"
+	migrate "github.com/louiehdev/gatorcli/internal/migrate"
 )
 
 type state struct {
 	cfg *config.Config
-	db  *database.Queries
+	// repo holds users, feeds, follows and posts; db is the full set of
+	// queries, for everything else. Outside tests both are the same
+	// Queries.
+	repo          repository
+	db            database.Querier
+	runTx         txFunc
+	migrator      *migrate.Migrator
+	schemaChecked bool
+	output        outputFormat
+	location      *time.Location
+}
+
+// inTx runs fn on a copy of s whose queries share one transaction, so
+// either all of fn's writes are kept or none are.
+func (s *state) inTx(ctx context.Context, fn func(tx *state) error) error {
+	return s.runTx(ctx, func(queries database.Querier) error {
+		txState := *s
+		txState.repo, txState.db = queries, queries
+		return fn(&txState)
+	})
 }
 
 type commandHandler func(s *state, cmd command) error
@@ -27,28 +49,158 @@ type commandHandler func(s *state, cmd command) error
 type command struct {
 	name      string
 	arguments []string
+	flags     *flag.FlagSet
+}
+
+// commandSpec describes a registered command. maxArgs of -1 accepts any
+// number of positional arguments, and args lists what each positional
+// argument is for shell completion. Commands that do not touch the schema
+// set skipSchemaCheck so they work against an out-of-date database.
+type commandSpec struct {
+	name            string
+	usage           string
+	description     string
+	minArgs         int
+	maxArgs         int
+	args            []argKind
+	hidden          bool
+	skipSchemaCheck bool
+	// listing commands render through state.render and accept --output
+	// after the command name as well as before it.
+	listing bool
+	flags   func(fs *flag.FlagSet)
+	handler commandHandler
 }
 
 type commands struct {
-	commandMap map[string]commandHandler
+	commandMap map[string]commandSpec
 }
 
 func (c *commands) run(s *state, cmd command) error {
-	if err := c.commandMap[cmd.name](s, cmd); err != nil {
-		return err
+	spec, ok := c.commandMap[cmd.name]
+	if !ok {
+		return c.unknownCommandError(cmd.name)
 	}
-	return nil
+	fs := spec.flagSet()
+	arguments, err := parseInterspersed(fs, cmd.arguments)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			spec.printHelp(os.Stdout)
+			return nil
+		}
+		return fmt.Errorf("%v\nusage: gator %s", err, spec.usage)
+	}
+	if len(arguments) < spec.minArgs {
+		return fmt.Errorf("not enough arguments provided\nusage: gator %s", spec.usage)
+	}
+	if spec.maxArgs >= 0 && len(arguments) > spec.maxArgs {
+		return fmt.Errorf("too many arguments provided\nusage: gator %s", spec.usage)
+	}
+	if !spec.skipSchemaCheck {
+		if err := s.checkSchema(); err != nil {
+			return err
+		}
+	}
+	if spec.listing {
+		if output := fs.Lookup("output").Value.(*outputFlag); output.set {
+			defer func(previous outputFormat) { s.output = previous }(s.output)
+			s.output = output.format
+		}
+	}
+	return spec.handler(s, command{name: cmd.name, arguments: arguments, flags: fs})
 }
 
-func (c *commands) register(name string, f commandHandler) error {
-	c.commandMap[name] = f
+func (c *commands) register(spec commandSpec) error {
+	if _, exists := c.commandMap[spec.name]; exists {
+		return fmt.Errorf("command %s registered twice", spec.name)
+	}
+	c.commandMap[spec.name] = spec
 	return nil
 }
 
+func (spec commandSpec) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(spec.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if spec.flags != nil {
+		spec.flags(fs)
+	}
+	if spec.listing {
+		output := &outputFlag{format: outputPlain}
+		fs.Var(output, "output", "output format: json, csv, table or plain")
+		fs.Var(output, "o", "shorthand for --output")
+	}
+	return fs
+}
+
+// parseInterspersed lets flags appear before, between or after positional
+// arguments. Everything after a literal "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	if idx := slices.Index(args, "--"); idx >= 0 {
+		args, rest = args[:idx], args[idx+1:]
+	}
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (cmd command) flagValue(name string) any {
+	if cmd.flags == nil {
+		return nil
+	}
+	f := cmd.flags.Lookup(name)
+	if f == nil {
+		return nil
+	}
+	return f.Value.(flag.Getter).Get()
+}
+
+func (cmd command) stringFlag(name string) string {
+	value, _ := cmd.flagValue(name).(string)
+	return value
+}
+
+func (cmd command) intFlag(name string) int {
+	value, _ := cmd.flagValue(name).(int)
+	return value
+}
+
+func (cmd command) boolFlag(name string) bool {
+	value, _ := cmd.flagValue(name).(bool)
+	return value
+}
+
+func (cmd command) durationFlag(name string) time.Duration {
+	value, _ := cmd.flagValue(name).(time.Duration)
+	return value
+}
+
+// flagPassed reports whether the flag was given explicitly on the command line.
+func (cmd command) flagPassed(name string) bool {
+	found := false
+	if cmd.flags != nil {
+		cmd.flags.Visit(func(f *flag.Flag) {
+			if f.Name == name {
+				found = true
+			}
+		})
+	}
+	return found
+}
+
 func middlewareLoggedIn(authedCommand func(s *state, cmd command, user database.User) error) commandHandler {
 	return func(s *state, cmd command) error {
 		ctx := context.Background()
-		currentUser, err := s.db.GetUser(ctx, s.cfg.Username)
+		currentUser, err := s.repo.GetUser(ctx, s.cfg.Username)
 		if err != nil {
 			return err
 		}
@@ -58,15 +210,14 @@ func middlewareLoggedIn(authedCommand func(s *state, cmd command, user database.
 }
 
 func commandLogin(s *state, cmd command) error {
-	if len(cmd.arguments) == 0 {
-		return fmt.Errorf("username required")
-	}
 	ctx := context.Background()
 	username := cmd.arguments[0]
 
-	if _, err := s.db.GetUser(ctx, username); err != nil {
-		fmt.Println("Username not recognized in database")
-		os.Exit(1)
+	if _, err := s.repo.GetUser(ctx, username); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("username not recognized in database")
+		}
+		return err
 	}
 
 	if err := s.cfg.SetUser(username); err != nil {
@@ -77,25 +228,18 @@ func commandLogin(s *state, cmd command) error {
 }
 
 func commandRegister(s *state, cmd command) error {
-	if len(cmd.arguments) == 0 {
-		return fmt.Errorf("name argument required")
-	}
 	ctx := context.Background()
 	name := cmd.arguments[0]
-	id := uuid.New().ID()
+	now := time.Now().UTC()
 	user := database.CreateUserParams{
-		ID:        int32(id),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 		Name:      name}
 
-	registeredUser, err := s.db.CreateUser(ctx, user)
+	registeredUser, err := s.repo.CreateUser(ctx, user)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			if string(pqErr.Code) == "23505" {
-				fmt.Println("User already exists")
-				os.Exit(1)
-			}
+		if isUniqueViolation(err) {
+			return fmt.Errorf("user already exists")
 		}
 		return err
 	}
@@ -107,44 +251,46 @@ func commandRegister(s *state, cmd command) error {
 }
 
 func commandUsers(s *state, cmd command) error {
-	if len(cmd.arguments) > 0 {
-		return fmt.Errorf("too many arguments provided")
-	}
 	ctx := context.Background()
-	users, err := s.db.GetUsers(ctx)
+	users, err := s.repo.GetUsers(ctx)
 	if err != nil {
 		return err
 	}
 
+	records := make([]userRecord, 0, len(users))
+	rows := make([][]string, 0, len(users))
 	for _, user := range users {
-		if user == s.cfg.Username {
-			fmt.Printf("%s (current)\n", user)
-		} else {
-			fmt.Println(user)
-		}
-	}
-
-	return nil
+		current := user == s.cfg.Username
+		records = append(records, userRecord{Name: user, Current: current})
+		rows = append(rows, []string{user, strconv.FormatBool(current)})
+	}
+
+	return s.render(listing{
+		columns: []string{"name", "current"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			for _, user := range records {
+				if user.Current {
+					fmt.Fprintf(w, "%s (current)\n", user.Name)
+				} else {
+					fmt.Fprintln(w, user.Name)
+				}
+			}
+		},
+	})
 }
 
 func commandReset(s *state, cmd command) error {
-	if len(cmd.arguments) > 0 {
-		return fmt.Errorf("too many arguments provided")
-	}
 	ctx := context.Background()
-	if err := s.db.ResetUsers(ctx); err != nil {
-		fmt.Printf("Reset unsuccessful: %v\n", err)
-		os.Exit(1)
+	if err := s.repo.ResetUsers(ctx); err != nil {
+		return fmt.Errorf("reset unsuccessful: %w", err)
 	}
 	fmt.Println("Reset successful")
-	os.Exit(0)
 	return nil
 }
 
 func commandAgg(s *state, cmd command) error {
-	if len(cmd.arguments) != 1 {
-		return fmt.Errorf("incorrect arguments provided")
-	}
 	timeBetweenRequests, err := time.ParseDuration(cmd.arguments[0])
 	if err != nil {
 		return err
@@ -154,40 +300,42 @@ func commandAgg(s *state, cmd command) error {
 	fmt.Printf("Collecting feeds every %s\n", timeBetweenRequests)
 
 	for ; ; <-ticker.C {
-		err := scrapeFeeds(s)
+		err := scrapeFeeds(s, cmd.boolFlag("prune"))
 		if err != nil {
 			fmt.Println(err)
 		}
+		if err := deliverWebhooks(context.Background(), s); err != nil {
+			fmt.Println(err)
+		}
+		if err := sendDueDigests(context.Background(), s); err != nil {
+			fmt.Println(err)
+		}
 	}
 }
 
 func commandAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.arguments) < 2 {
-		return fmt.Errorf("not enough arguments provided")
-	}
 	ctx := context.Background()
 	feedName := cmd.arguments[0]
 	feedURL := cmd.arguments[1]
+	now := time.Now().UTC()
 	newFeed := database.CreateFeedParams{
-		ID:        int32(uuid.New().ID()),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 		Name:      feedName,
 		Url:       feedURL,
 		UserID:    user.ID}
 
-	addedFeed, err := s.db.CreateFeed(ctx, newFeed)
+	addedFeed, err := s.repo.CreateFeed(ctx, newFeed)
 	if err != nil {
 		return err
 	}
 	newFeedFollow := database.CreateFeedFollowParams{
-		ID:        int32(uuid.New().ID()),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 		UserID:    user.ID,
 		FeedID:    addedFeed.ID}
 
-	if _, followErr := s.db.CreateFeedFollow(ctx, newFeedFollow); followErr != nil {
+	if _, followErr := s.repo.CreateFeedFollow(ctx, newFeedFollow); followErr != nil {
 		return followErr
 	}
 
@@ -196,39 +344,88 @@ func commandAddFeed(s *state, cmd command, user database.User) error {
 }
 
 func commandFeeds(s *state, cmd command) error {
-	if len(cmd.arguments) > 0 {
-		return fmt.Errorf("too many arguments provided")
-	}
 	ctx := context.Background()
-	feeds, err := s.db.GetFeeds(ctx)
+	feeds, err := s.repo.GetFeeds(ctx)
 	if err != nil {
 		return err
 	}
+	records := make([]feedRecord, 0, len(feeds))
+	rows := make([][]string, 0, len(feeds))
 	for _, feed := range feeds {
-		fmt.Printf("Feed Name: %s | URL: %s | User Name: %s\n", feed.Name, feed.Url, feed.UserName)
+		records = append(records, feedRecord{Name: feed.Name, Url: feed.Url, UserName: feed.UserName})
+		rows = append(rows, []string{feed.Name, feed.Url, feed.UserName})
 	}
 
-	return nil
+	return s.render(listing{
+		columns: []string{"name", "url", "user_name"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			for _, feed := range records {
+				fmt.Fprintf(w, "Feed Name: %s | URL: %s | User Name: %s\n", feed.Name, feed.Url, feed.UserName)
+			}
+		},
+	})
 }
 
-func commandFollow(s *state, cmd command, user database.User) error {
-	if len(cmd.arguments) != 1 {
-		return fmt.Errorf("incorrect arguments provided")
+// resolveFeed looks a feed up by URL, falling back to its name.
+func resolveFeed(ctx context.Context, s *state, urlOrName string) (database.Feed, error) {
+	feed, err := s.repo.GetFeedFromURL(ctx, urlOrName)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return feed, err
+	}
+	feeds, err := s.repo.GetFeedsByName(ctx, urlOrName)
+	if err != nil {
+		return database.Feed{}, err
+	}
+	switch len(feeds) {
+	case 0:
+		return database.Feed{}, fmt.Errorf("no feed with URL or name %q", urlOrName)
+	case 1:
+		return feeds[0], nil
+	default:
+		return database.Feed{}, fmt.Errorf("several feeds are named %q, use the feed URL instead", urlOrName)
+	}
+}
+
+// resolveUserFeed is resolveFeed for commands run by user: the names user
+// gave their followed feeds with rename-feed are tried first.
+func resolveUserFeed(ctx context.Context, s *state, user database.User, urlOrName string) (database.Feed, error) {
+	follows, err := s.repo.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return database.Feed{}, err
+	}
+	var matches []database.GetFeedFollowsForUserRow
+	for _, follow := range follows {
+		if follow.DisplayName.Valid && follow.DisplayName.String == urlOrName {
+			matches = append(matches, follow)
+		}
 	}
+	switch len(matches) {
+	case 0:
+		return resolveFeed(ctx, s, urlOrName)
+	case 1:
+		return s.repo.GetFeedFromURL(ctx, matches[0].FeedUrl)
+	default:
+		return database.Feed{}, fmt.Errorf("several of your feeds are named %q, use the feed URL instead", urlOrName)
+	}
+}
+
+func commandFollow(s *state, cmd command, user database.User) error {
 	ctx := context.Background()
-	feed, err := s.db.GetFeedFromURL(ctx, cmd.arguments[0])
+	feed, err := resolveUserFeed(ctx, s, user, cmd.arguments[0])
 	if err != nil {
 		return err
 	}
 
+	now := time.Now().UTC()
 	feedFollowParams := database.CreateFeedFollowParams{
-		ID:        int32(uuid.New().ID()),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 		UserID:    user.ID,
 		FeedID:    feed.ID,
 	}
-	feedFollow, err := s.db.CreateFeedFollow(ctx, feedFollowParams)
+	feedFollow, err := s.repo.CreateFeedFollow(ctx, feedFollowParams)
 	if err != nil {
 		return err
 	}
@@ -237,74 +434,251 @@ func commandFollow(s *state, cmd command, user database.User) error {
 }
 
 func commandFollowing(s *state, cmd command, user database.User) error {
-	if len(cmd.arguments) != 0 {
-		return fmt.Errorf("too many arguments provided")
-	}
 	ctx := context.Background()
-	feedFollows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	feedFollows, err := s.repo.GetFeedFollowsForUser(ctx, user.ID)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("User %s is currently following:\n", user.Name)
-	for _, feedFollow := range feedFollows {
-		fmt.Printf(" - %s\n", feedFollow.FeedName)
+	folders, err := s.db.GetFoldersForUser(ctx, user.ID)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	searches, err := s.db.GetSavedSearchesForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	records := make([]followRecord, 0, len(feedFollows)+len(searches))
+	rows := make([][]string, 0, len(feedFollows))
+	for _, feedFollow := range feedFollows {
+		followedAt := s.localTime(feedFollow.CreatedAt).Format(time.RFC3339)
+		records = append(records, followRecord{
+			FeedName:   feedFollow.FeedName,
+			FeedUrl:    feedFollow.FeedUrl,
+			Folder:     feedFollow.FolderName.String,
+			FollowedAt: followedAt,
+		})
+		rows = append(rows, []string{feedFollow.FeedName, feedFollow.FeedUrl, feedFollow.FolderName.String, followedAt, ""})
+	}
+	// Saved searches are listed as virtual feeds, with their criteria in
+	// place of a URL.
+	for _, search := range searches {
+		savedAt := s.localTime(search.CreatedAt).Format(time.RFC3339)
+		criteria := savedSearchCriteria(search).String()
+		records = append(records, followRecord{
+			FeedName:    search.Name,
+			FollowedAt:  savedAt,
+			SavedSearch: criteria,
+		})
+		rows = append(rows, []string{search.Name, "", "", savedAt, criteria})
+	}
+
+	return s.render(listing{
+		columns: []string{"feed_name", "feed_url", "folder", "followed_at", "saved_search"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			fmt.Fprintf(w, "User %s is currently following:\n", user.Name)
+			// Follows come sorted by folder, so each folder's feeds are
+			// printed under it; empty folders are listed too.
+			for _, folder := range folders {
+				fmt.Fprintf(w, " %s/\n", folder.Name)
+				for _, feedFollow := range records {
+					if feedFollow.Folder == folder.Name {
+						fmt.Fprintf(w, "   - %s\n", feedFollow.FeedName)
+					}
+				}
+			}
+			for _, feedFollow := range records {
+				if feedFollow.Folder == "" && feedFollow.SavedSearch == "" {
+					fmt.Fprintf(w, " - %s\n", feedFollow.FeedName)
+				}
+			}
+			if len(searches) > 0 {
+				fmt.Fprintln(w, "Saved searches:")
+				for _, feedFollow := range records {
+					if feedFollow.SavedSearch != "" {
+						fmt.Fprintf(w, " - %s (%s)\n", feedFollow.FeedName, feedFollow.SavedSearch)
+					}
+				}
+			}
+		},
+	})
 }
 
 func commandUnfollow(s *state, cmd command, user database.User) error {
-	if len(cmd.arguments) != 1 {
-		return fmt.Errorf("incorrect arguments provided")
+	ctx := context.Background()
+	feed, err := resolveUserFeed(ctx, s, user, cmd.arguments[0])
+	if err != nil {
+		return err
 	}
+	if err := s.repo.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{UserID: user.ID, FeedID: feed.ID}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// commandRenameFeed sets the name the current user sees for a followed
+// feed, or goes back to the feed's own name when no name is given.
+func commandRenameFeed(s *state, cmd command, user database.User) error {
 	ctx := context.Background()
-	feed, err := s.db.GetFeedFromURL(ctx, cmd.arguments[0])
+	feed, err := resolveUserFeed(ctx, s, user, cmd.arguments[0])
 	if err != nil {
 		return err
 	}
-	if err := s.db.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{UserID: user.ID, FeedID: feed.ID}); err != nil {
+	var displayName sql.NullString
+	if len(cmd.arguments) == 2 {
+		name := strings.TrimSpace(cmd.arguments[1])
+		displayName = sql.NullString{String: name, Valid: name != "" && name != feed.Name}
+	}
+	updated, err := s.repo.SetFeedFollowDisplayName(ctx, database.SetFeedFollowDisplayNameParams{
+		UserID:      user.ID,
+		FeedID:      feed.ID,
+		DisplayName: displayName,
+		UpdatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
 		return err
 	}
+	if updated == 0 {
+		return fmt.Errorf("you are not following %s", feed.Name)
+	}
+	if displayName.Valid {
+		fmt.Printf("%s is now shown as %s\n", feed.Name, displayName.String)
+	} else {
+		fmt.Printf("%s is shown under its own name again\n", feed.Name)
+	}
 	return nil
 }
 
 func commandBrowse(s *state, cmd command, user database.User) error {
-	var limit int32
+	ctx := context.Background()
+	limit := int32(cmd.intFlag("limit"))
 	if len(cmd.arguments) == 1 {
 		limitarg, err := strconv.Atoi(cmd.arguments[0])
 		if err != nil {
-			return err
+			return fmt.Errorf("invalid limit %q", cmd.arguments[0])
 		}
 		limit = int32(limitarg)
-	} else if len(cmd.arguments) < 1 {
-		limit = 2
-	} else {
-		return fmt.Errorf("too many arguments provided")
 	}
+	criteria := searchCriteria{tag: cmd.stringFlag("tag"), folder: cmd.stringFlag("folder")}
+	if cmd.flagPassed("saved") {
+		if cmd.flagPassed("tag") || cmd.flagPassed("folder") {
+			return errors.New("--saved cannot be combined with --tag or --folder")
+		}
+		saved, err := getSavedSearch(ctx, s, user, cmd.stringFlag("saved"))
+		if err != nil {
+			return err
+		}
+		criteria = saved
+	}
+	params, err := criteria.postsParams(ctx, s, user, limit)
+	if err != nil {
+		return err
+	}
+	return listPosts(s, cmd, params)
+}
 
+// commandSearch lists followed posts whose title, summary or content
+// contains the query, ignoring case. With --save the search is also kept
+// under a name for browse --saved.
+func commandSearch(s *state, cmd command, user database.User) error {
 	ctx := context.Background()
-	posts, err := s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, Limit: limit})
+	criteria := searchCriteria{
+		query:      strings.TrimSpace(cmd.arguments[0]),
+		tag:        cmd.stringFlag("tag"),
+		folder:     cmd.stringFlag("folder"),
+		withinDays: int32(cmd.intFlag("days")),
+	}
+	if criteria == (searchCriteria{}) {
+		return errors.New("search query is empty")
+	}
+	if criteria.withinDays < 0 {
+		return errors.New("--days must not be negative")
+	}
+	params, err := criteria.postsParams(ctx, s, user, int32(cmd.intFlag("limit")))
 	if err != nil {
 		return err
 	}
+	if cmd.flagPassed("save") {
+		if err := saveSearch(ctx, s, user, cmd.stringFlag("save"), criteria); err != nil {
+			return err
+		}
+	}
+	return listPosts(s, cmd, params)
+}
 
-	for _, post := range posts {
-		fmt.Println(post)
+// listPosts renders the posts matching params with their enclosures and
+// tags, for browse and search, after applying the user's filter rules.
+func listPosts(s *state, cmd command, params database.GetPostsForUserParams) error {
+	ctx := context.Background()
+	rules, err := loadFilterRules(ctx, s, params.UserID)
+	if err != nil {
+		return err
+	}
+	posts, verdicts, err := filteredPosts(ctx, s, rules, params)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	records := make([]postRecord, 0, len(posts))
+	rows := make([][]string, 0, len(posts))
+	details := make(map[int64]postDetails, len(posts))
+	for _, post := range posts {
+		enclosures, err := s.db.GetEnclosuresForPost(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		tags, err := s.db.GetTagsForPost(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		highlighted := verdicts[post.ID].highlighted
+		details[post.ID] = postDetails{enclosures: enclosures, tags: tags, highlighted: highlighted}
+		publishedAt := s.localTime(post.PublishedAt).Format(time.RFC3339)
+		record := postRecord{
+			ID:          post.ID,
+			FeedName:    post.FeedName,
+			Title:       post.Title,
+			Url:         post.Url,
+			PublishedAt: publishedAt,
+			Description: post.Description,
+			Content:     post.Content,
+			Author:      post.Author,
+			Tags:        tags,
+			Highlighted: highlighted,
+		}
+		for _, enc := range enclosures {
+			record.Enclosures = append(record.Enclosures, enclosureRecord{
+				Url:             enc.Url,
+				MimeType:        enc.MimeType,
+				Length:          enc.Length,
+				DurationSeconds: enc.DurationSeconds.Int32,
+			})
+		}
+		records = append(records, record)
+		rows = append(rows, []string{strconv.FormatInt(post.ID, 10), post.FeedName, post.Title, post.Url, publishedAt, strings.Join(tags, ", ")})
+	}
+
+	return s.render(listing{
+		columns: []string{"id", "feed_name", "title", "url", "published_at", "tags"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			printPosts(w, posts, details, s.location, s.showFullContent(cmd))
+		},
+	})
 }
 
-func scrapeFeeds(s *state) error {
+func scrapeFeeds(s *state, prune bool) error {
 	ctx := context.Background()
-	nextFeed, err := s.db.GetNextFeedToFetch(ctx)
+	nextFeed, err := s.repo.GetNextFeedToFetch(ctx)
 	if err != nil {
 		return err
 	}
-	if err := s.db.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+	if err := s.repo.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
 		ID: nextFeed.ID,
 		LastFetchedAt: sql.NullTime{
-			Time:  time.Now(),
+			Time:  time.Now().UTC(),
 			Valid: true,
 		}}); err != nil {
 		return err
@@ -313,27 +687,87 @@ func scrapeFeeds(s *state) error {
 	if err != nil {
 		return err
 	}
-	for _, item := range rssFeed.Channel.Items[:3] {
-		publishTime, _ := time.Parse(time.RFC1123Z, item.PubDate)
+	webhooks, err := s.db.GetWebhooksForFeed(ctx, nextFeed.ID)
+	if err != nil {
+		return err
+	}
+	items := rssFeed.Channel.Items
+	for _, item := range items[:min(3, len(items))] {
+		now := time.Now().UTC()
+		// Items without a date we can read are dated when they were
+		// fetched, so they sort and age like new posts.
+		publishTime := parsePubDate(item.PubDate)
+		if publishTime.IsZero() {
+			publishTime = now
+		}
 		newPost := database.CreatePostParams{
-			ID:          int32(uuid.New().ID()),
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-			PublishedAt: publishTime,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			PublishedAt: publishTime.UTC(),
 			Title:       item.Title,
 			Url:         item.Link,
-			Description: item.Description,
+			Description: sanitizeHTML(item.Description),
+			Content:     sanitizeHTML(item.Content),
+			Author:      item.author(),
 			FeedID:      nextFeed.ID,
 		}
-		if _, err := s.db.CreatePost(ctx, newPost); err != nil {
-			if pqErr, ok := err.(*pq.Error); ok {
-				if string(pqErr.Code) == "23505" {
-					fmt.Println("Post already exists, ignoring error for now")
-					return nil
+		if nextFeed.FullText && item.Link != "" {
+			// Only new posts are worth the page fetch; stored ones would be
+			// turned away by CreatePost below.
+			exists, err := s.repo.PostExists(ctx, item.Link)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			article, err := fullTextArticle(ctx, s, item.Link)
+			if err != nil {
+				fmt.Printf("Full text for %s: %v\n", item.Link, err)
+			}
+			if len(htmlToText(article)) > len(htmlToText(newPost.Content)) {
+				newPost.Content = sanitizeHTML(article)
+			}
+		}
+		// A post is stored with its enclosures, tags and webhook deliveries
+		// or not at all.
+		err := s.inTx(ctx, func(tx *state) error {
+			post, err := tx.repo.CreatePost(ctx, newPost)
+			if err != nil {
+				return err
+			}
+			enclosures, tags := item.enclosures(), item.tags()
+			if err := storeEnclosures(ctx, tx, post.ID, enclosures); err != nil {
+				return err
+			}
+			for _, tag := range tags {
+				if err := tx.db.AddPostTag(ctx, database.AddPostTagParams{PostID: post.ID, Tag: tag}); err != nil {
+					return err
 				}
 			}
+			return queueWebhooks(ctx, tx, webhooks, nextFeed, post, tags, enclosures)
+		})
+		if isUniqueViolation(err) {
+			fmt.Println("Post already exists, ignoring error for now")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if nextFeed.FullText {
+		if err := s.db.DeleteExpiredArticles(ctx, time.Now().UTC()); err != nil {
 			return err
 		}
 	}
+	if prune {
+		deleted, err := pruneFeed(ctx, s, nextFeed, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			fmt.Printf("Pruned %s from %s\n", pluralize(int(deleted), "post"), nextFeed.Name)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+func runTestCommand(t *testing.T, s *state, handler commandHandler, name string, args ...string) error {
+	t.Helper()
+	return handler(s, command{name: name, arguments: args})
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newMemoryState(t)
+	if err := runTestCommand(t, s, commandRegister, "register", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	if s.cfg.Username != "ann" {
+		t.Errorf("current user is %q after register, want ann", s.cfg.Username)
+	}
+	if err := runTestCommand(t, s, commandRegister, "register", "ann"); err == nil {
+		t.Error("registering ann twice succeeded")
+	}
+	if err := runTestCommand(t, s, commandRegister, "register", "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runTestCommand(t, s, commandLogin, "login", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	if s.cfg.Username != "ann" {
+		t.Errorf("current user is %q after login, want ann", s.cfg.Username)
+	}
+	if err := runTestCommand(t, s, commandLogin, "login", "carol"); err == nil {
+		t.Error("logging in as an unknown user succeeded")
+	}
+
+	users, err := s.repo.GetUsers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Errorf("got users %q, want ann and bob", users)
+	}
+}
+
+func TestFollowAndUnfollow(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryState(t)
+	for _, name := range []string{"ann", "bob"} {
+		if err := runTestCommand(t, s, commandRegister, "register", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandAddFeed), "addfeed", "Go Blog", "https://go.dev/blog/feed.atom"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runTestCommand(t, s, commandLogin, "login", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandFollow), "follow", "Go Blog"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandFollow), "follow", "Go Blog"); err == nil {
+		t.Error("following a feed twice succeeded")
+	}
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandFollow), "follow", "No Such Feed"); err == nil {
+		t.Error("following an unknown feed succeeded")
+	}
+
+	ann, err := s.repo.GetUser(ctx, "ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	follows := followedFeedNames(t, s, ann)
+	if len(follows) != 1 || follows[0] != "Go Blog" {
+		t.Fatalf("ann follows %q, want Go Blog", follows)
+	}
+
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandUnfollow), "unfollow", "https://go.dev/blog/feed.atom"); err != nil {
+		t.Fatal(err)
+	}
+	if follows := followedFeedNames(t, s, ann); len(follows) != 0 {
+		t.Errorf("ann still follows %q after unfollowing", follows)
+	}
+}
+
+func TestRenameFeed(t *testing.T) {
+	s := newMemoryState(t)
+	user, feed := createTestFeed(t, s, "ann")
+	s.cfg.Username = user.Name
+
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandRenameFeed), "rename-feed", feed.Url, "Mine"); err != nil {
+		t.Fatal(err)
+	}
+	if follows := followedFeedNames(t, s, user); len(follows) != 1 || follows[0] != "Mine" {
+		t.Errorf("follows are named %q, want Mine", follows)
+	}
+	// Commands that take a feed find it by the name the user gave it.
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandRenameFeed), "rename-feed", "Mine", "Ours"); err != nil {
+		t.Fatal(err)
+	}
+	if follows := followedFeedNames(t, s, user); len(follows) != 1 || follows[0] != "Ours" {
+		t.Errorf("follows are named %q, want Ours", follows)
+	}
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandRenameFeed), "rename-feed", feed.Url); err != nil {
+		t.Fatal(err)
+	}
+	if follows := followedFeedNames(t, s, user); len(follows) != 1 || follows[0] != feed.Name {
+		t.Errorf("follows are named %q, want %q", follows, feed.Name)
+	}
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandRenameFeed), "rename-feed", feed.Url, "Mine"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runTestCommand(t, s, middlewareLoggedIn(commandUnfollow), "unfollow", "Mine"); err != nil {
+		t.Fatal(err)
+	}
+	if follows := followedFeedNames(t, s, user); len(follows) != 0 {
+		t.Errorf("still following %q after unfollowing by display name", follows)
+	}
+}
+
+func followedFeedNames(t *testing.T, s *state, user database.User) []string {
+	t.Helper()
+	follows, err := s.repo.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, follow := range follows {
+		names = append(names, follow.FeedName)
+	}
+	return names
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// argKind tells shell completion what a positional argument expects.
+type argKind string
+
+const (
+	argUser          argKind = "user"
+	argFeedURL       argKind = "feed_url"
+	argFeedName      argKind = "feed_name"
+	argFeed          argKind = "feed"
+	argCommand       argKind = "command"
+	argShell         argKind = "shell"
+	argMigrateAction argKind = "migrate_action"
+	argOnOff         argKind = "on_off"
+	argFolderAction  argKind = "folder_action"
+	argOPMLAction    argKind = "opml_action"
+	argFilterAction  argKind = "filter_action"
+	argWebhookAction argKind = "webhook_action"
+	argDigestAction  argKind = "digest_action"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+var migrateActions = []string{"up", "down", "status"}
+
+var onOffValues = []string{"on", "off"}
+
+var folderActions = []string{"create", "rename", "delete", "move"}
+
+var opmlActions = []string{"import", "export"}
+
+var filterActions = []string{"add", "delete", "list", "test"}
+
+var webhookActions = []string{"add", "delete", "list", "log"}
+
+var digestActions = []string{"preview", "send", "schedule"}
+
+func (c *commands) commandCompletion(s *state, cmd command) error {
+	switch cmd.arguments[0] {
+	case "bash":
+		c.writeBashCompletion(os.Stdout)
+	case "zsh":
+		c.writeZshCompletion(os.Stdout)
+	case "fish":
+		c.writeFishCompletion(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported shell %q (expected bash, zsh or fish)", cmd.arguments[0])
+	}
+	return nil
+}
+
+// commandComplete prints completion candidates for the given argument kind,
+// one per line. Generated shell scripts call it as "gator __complete <kind>".
+func (c *commands) commandComplete(s *state, cmd command) error {
+	candidates, err := c.completionCandidates(context.Background(), s, argKind(cmd.arguments[0]))
+	if err != nil {
+		return err
+	}
+	for _, candidate := range candidates {
+		fmt.Println(candidate)
+	}
+	return nil
+}
+
+func (c *commands) completionCandidates(ctx context.Context, s *state, kind argKind) ([]string, error) {
+	switch kind {
+	case argCommand:
+		return c.visibleNames(), nil
+	case argShell:
+		return completionShells, nil
+	case argMigrateAction:
+		return migrateActions, nil
+	case argOnOff:
+		return onOffValues, nil
+	case argFolderAction:
+		return folderActions, nil
+	case argOPMLAction:
+		return opmlActions, nil
+	case argFilterAction:
+		return filterActions, nil
+	case argWebhookAction:
+		return webhookActions, nil
+	case argDigestAction:
+		return digestActions, nil
+	case argUser:
+		return s.repo.GetUsers(ctx)
+	case argFeedURL, argFeedName, argFeed:
+		feeds, err := s.repo.GetFeeds(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var candidates []string
+		for _, feed := range feeds {
+			if kind != argFeedName {
+				candidates = append(candidates, feed.Url)
+			}
+			if kind != argFeedURL {
+				candidates = append(candidates, feed.Name)
+			}
+		}
+		return candidates, nil
+	}
+	return nil, fmt.Errorf("unknown completion kind %q", kind)
+}
+
+func (c *commands) visibleNames() []string {
+	var names []string
+	for _, name := range c.names() {
+		if !c.commandMap[name].hidden {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (spec commandSpec) flagNames() []string {
+	var names []string
+	spec.flagSet().VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+	})
+	return names
+}
+
+func (c *commands) writeBashCompletion(w io.Writer) {
+	fmt.Fprintf(w, `# bash completion for gator
+# Load with: source <(gator completion bash)
+_gator() {
+    local cur prev
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        _get_comp_words_by_ref -n : cur prev
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        prev="${COMP_WORDS[COMP_CWORD-1]}"
+    fi
+
+    local i=1 cmd=""
+    while [ $i -lt $COMP_CWORD ]; do
+        case "${COMP_WORDS[i]}" in
+            -o|--output) i=$((i+2)) ;;
+            -*) i=$((i+1)) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    if [ -z "$cmd" ]; then
+        case "$prev" in
+            -o|--output) COMPREPLY=($(compgen -W "json csv table plain" -- "$cur")) ;;
+            *) COMPREPLY=($(compgen -W "%s --output" -- "$cur")) ;;
+        esac
+        return
+    fi
+
+    if [[ "$cur" == -* ]]; then
+        case "$cmd" in
+`, strings.Join(c.visibleNames(), " "))
+	for _, name := range c.visibleNames() {
+		if flags := c.commandMap[name].flagNames(); len(flags) > 0 {
+			fmt.Fprintf(w, "            %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", name, strings.Join(flags, " "))
+		}
+	}
+	fmt.Fprint(w, `        esac
+        return
+    fi
+
+    local pos=0 j
+    for ((j=i+1; j<COMP_CWORD; j++)); do
+        [[ "${COMP_WORDS[j]}" != -* ]] && pos=$((pos+1))
+    done
+
+    local kind=""
+    case "$cmd:$pos" in
+`)
+	for _, name := range c.visibleNames() {
+		for pos, kind := range c.commandMap[name].args {
+			fmt.Fprintf(w, "        %s:%d) kind=%s ;;\n", name, pos, kind)
+		}
+	}
+	fmt.Fprint(w, `    esac
+    [ -z "$kind" ] && return
+
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$(gator __complete "$kind" 2>/dev/null)" -- "$cur"))
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -F _gator gator
+`)
+}
+
+func (c *commands) writeZshCompletion(w io.Writer) {
+	fmt.Fprint(w, `#compdef gator
+# zsh completion for gator
+# Load with: source <(gator completion zsh)
+_gator() {
+    local -a cmds
+    cmds=(
+`)
+	for _, name := range c.visibleNames() {
+		fmt.Fprintf(w, "        %s\n", zshQuote(name+":"+c.commandMap[name].description))
+	}
+	fmt.Fprint(w, `    )
+
+    local i=2 cmd=""
+    while (( i < CURRENT )); do
+        case "${words[i]}" in
+            -o|--output) (( i += 2 )) ;;
+            -*) (( i += 1 )) ;;
+            *) cmd="${words[i]}"; break ;;
+        esac
+    done
+
+    if [[ -z "$cmd" ]]; then
+        if [[ "${words[CURRENT-1]}" == (-o|--output) ]]; then
+            compadd json csv table plain
+        else
+            _describe 'command' cmds
+        fi
+        return
+    fi
+
+    if [[ "${words[CURRENT]}" == -* ]]; then
+        case "$cmd" in
+`)
+	for _, name := range c.visibleNames() {
+		if flags := c.commandMap[name].flagNames(); len(flags) > 0 {
+			fmt.Fprintf(w, "            %s) compadd -- %s ;;\n", name, strings.Join(flags, " "))
+		}
+	}
+	fmt.Fprint(w, `        esac
+        return
+    fi
+
+    local pos=0 j
+    for (( j = i + 1; j < CURRENT; j++ )); do
+        [[ "${words[j]}" != -* ]] && (( pos++ ))
+    done
+
+    local kind=""
+    case "$cmd:$pos" in
+`)
+	for _, name := range c.visibleNames() {
+		for pos, kind := range c.commandMap[name].args {
+			fmt.Fprintf(w, "        %s:%d) kind=%s ;;\n", name, pos, kind)
+		}
+	}
+	fmt.Fprint(w, `    esac
+    [[ -z "$kind" ]] && return
+
+    local -a candidates
+    candidates=("${(@f)$(gator __complete "$kind" 2>/dev/null)}")
+    compadd -a candidates
+}
+
+if [[ "$funcstack[1]" == "_gator" ]]; then
+    _gator "$@"
+else
+    compdef _gator gator
+fi
+`)
+}
+
+func (c *commands) writeFishCompletion(w io.Writer) {
+	fmt.Fprint(w, `# fish completion for gator
+# Load with: gator completion fish | source
+complete -c gator -f
+complete -c gator -n '__fish_use_subcommand' -s o -l output -x -a 'json csv table plain' -d 'Output format'
+`)
+	for _, name := range c.visibleNames() {
+		spec := c.commandMap[name]
+		fmt.Fprintf(w, "complete -c gator -n '__fish_use_subcommand' -a %s -d %s\n", fishQuote(name), fishQuote(spec.description))
+		spec.flagSet().VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, "complete -c gator -n '__fish_seen_subcommand_from %s' -l %s -d %s\n", name, f.Name, fishQuote(f.Usage))
+		})
+		for pos, kind := range spec.args {
+			condition := fmt.Sprintf("__fish_seen_subcommand_from %s; and test (count (commandline -opc | string match -v -- '-*')) -eq %d", name, pos+2)
+			fmt.Fprintf(w, "complete -c gator -n %s -a '(gator __complete %s 2>/dev/null)'\n", fishQuote(condition), kind)
+		}
+	}
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/louiehdev/gatorcli/internal/config"
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+const (
+	// digestMaxPosts caps how many posts one digest lists; the rest are
+	// only counted.
+	digestMaxPosts = 100
+	// digestRetryDelay is how long agg waits before trying a scheduled
+	// digest again after it could not be sent.
+	digestRetryDelay = time.Hour
+)
+
+var digestPeriods = map[string]time.Duration{
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// digest is one user's unread posts since a point in time, grouped by
+// feed in the order of each feed's newest post. Count is every unread
+// post, More the ones past digestMaxPosts that are left out of Feeds.
+type digest struct {
+	User  string
+	Since time.Time
+	Count int
+	More  int
+	Feeds []digestFeed
+}
+
+type digestFeed struct {
+	Name  string
+	Posts []digestPost
+}
+
+type digestPost struct {
+	Title       string
+	Url         string
+	Author      string
+	Published   time.Time
+	Summary     string
+	Highlighted bool
+}
+
+var digestHTML = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; max-width: 40em">
+<h1 style="font-size: 1.3em">{{.User}}: {{.Count}} unread since {{.Since.Format "Mon Jan 2 15:04"}}</h1>
+{{range .Feeds}}<h2 style="font-size: 1.1em; border-bottom: 1px solid #ccc">{{.Name}}</h2>
+{{range .Posts}}<div style="margin-bottom: 1em">
+<a href="{{.Url}}"{{if .Highlighted}} style="font-weight: bold"{{end}}>{{.Title}}</a>{{if .Highlighted}} &#9733;{{end}}<br>
+<small style="color: #666">{{if .Author}}{{.Author}} &middot; {{end}}{{.Published.Format "Mon Jan 2 15:04"}}</small>
+{{if .Summary}}<p style="margin: 0.3em 0">{{.Summary}}</p>{{end}}
+</div>
+{{end}}{{end}}{{if .More}}<p>&hellip;and {{.More}} more unread in gator.</p>
+{{end}}</body>
+</html>
+`))
+
+// commandDigest builds email digests of the current user's unread posts:
+//
+//	digest preview [--days n] [--html]
+//	digest send [--days n] [--to address]
+//	digest schedule [daily|weekly|off] [address]
+//
+// Scheduled digests are sent by agg. Without --days a digest covers the
+// time since the last one, or one schedule period.
+func commandDigest(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	action, args := cmd.arguments[0], cmd.arguments[1:]
+	if action == "schedule" {
+		return scheduleDigest(ctx, s, user, args)
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("usage: digest %s", action)
+	}
+	schedule, err := s.db.GetDigestSchedule(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	now := time.Now().UTC()
+	since := digestSince(schedule, now)
+	if days := cmd.intFlag("days"); days > 0 {
+		since = now.AddDate(0, 0, -days)
+	}
+	d, err := compileDigest(ctx, s, user, since)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "preview":
+		if cmd.boolFlag("html") {
+			return digestHTML.Execute(os.Stdout, d)
+		}
+		fmt.Print(d.text())
+	case "send":
+		to := schedule.Email
+		if cmd.flagPassed("to") {
+			address, err := mail.ParseAddress(cmd.stringFlag("to"))
+			if err != nil {
+				return fmt.Errorf("invalid email address %q", cmd.stringFlag("to"))
+			}
+			to = address.Address
+		}
+		if to == "" {
+			return errors.New("no address to send to: pass --to or set one with digest schedule")
+		}
+		if d.Count == 0 {
+			fmt.Println("No unread posts, nothing to send")
+			return nil
+		}
+		if err := sendDigest(s.cfg.SMTP, to, d, now); err != nil {
+			return err
+		}
+		if schedule.UserID != 0 {
+			if err := s.db.MarkDigestSent(ctx, database.MarkDigestSentParams{UserID: user.ID, LastSentAt: sql.NullTime{Time: now, Valid: true}}); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Sent a digest of %s to %s\n", pluralize(d.Count, "post"), to)
+	default:
+		return fmt.Errorf("unknown digest action %q (expected %s)", action, strings.Join(digestActions, ", "))
+	}
+	return nil
+}
+
+func scheduleDigest(ctx context.Context, s *state, user database.User, args []string) error {
+	if len(args) == 0 {
+		schedule, err := s.db.GetDigestSchedule(ctx, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Println("No digest scheduled")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Sending a %s digest to %s", schedule.Frequency, schedule.Email)
+		if schedule.LastSentAt.Valid {
+			fmt.Printf(", last sent %s", relativeTime(schedule.LastSentAt.Time, time.Now()))
+		}
+		fmt.Println()
+		return nil
+	}
+	frequency := args[0]
+	if frequency == "off" {
+		if len(args) != 1 {
+			return errors.New("usage: digest schedule off")
+		}
+		if _, err := s.db.DeleteDigestSchedule(ctx, user.ID); err != nil {
+			return err
+		}
+		fmt.Println("Digest turned off")
+		return nil
+	}
+	if _, ok := digestPeriods[frequency]; !ok {
+		return fmt.Errorf("unknown digest schedule %q (expected daily, weekly or off)", frequency)
+	}
+	var email string
+	if len(args) == 2 {
+		address, err := mail.ParseAddress(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid email address %q", args[1])
+		}
+		email = address.Address
+	} else {
+		current, err := s.db.GetDigestSchedule(ctx, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("usage: digest schedule daily|weekly <address>")
+		}
+		if err != nil {
+			return err
+		}
+		email = current.Email
+	}
+	now := time.Now().UTC()
+	if _, err := s.db.SetDigestSchedule(ctx, database.SetDigestScheduleParams{
+		UserID:    user.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Email:     email,
+		Frequency: frequency,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Sending a %s digest to %s while agg runs\n", frequency, email)
+	return nil
+}
+
+// digestSince is where a digest for schedule starts: the last one sent,
+// or one period back. Without a schedule it covers a day.
+func digestSince(schedule database.DigestSchedule, now time.Time) time.Time {
+	if schedule.LastSentAt.Valid {
+		return schedule.LastSentAt.Time
+	}
+	if period, ok := digestPeriods[schedule.Frequency]; ok {
+		return now.Add(-period)
+	}
+	return now.Add(-24 * time.Hour)
+}
+
+// compileDigest collects the user's unread posts that gator stored since
+// the given time, leaving out posts their filter rules hide. Going by when
+// posts were stored rather than published keeps posts that arrive late or
+// with old dates from being missed. Only the newest digestMaxPosts posts
+// are listed, but every post is counted, as the digest marks them all as
+// covered.
+func compileDigest(ctx context.Context, s *state, user database.User, since time.Time) (digest, error) {
+	rules, err := loadFilterRules(ctx, s, user.ID)
+	if err != nil {
+		return digest{}, err
+	}
+	d := digest{User: user.Name, Since: s.localTime(since)}
+	feeds := make(map[string]int)
+	params := database.GetPostsForUserParams{
+		UserID:       user.ID,
+		CreatedAfter: since,
+		UnreadOnly:   true,
+		RowLimit:     digestMaxPosts,
+	}
+	for {
+		page, err := s.db.GetPostsForUser(ctx, params)
+		if err != nil {
+			return digest{}, err
+		}
+		for _, post := range page {
+			verdict := evaluateFilters(rules, postForFilters(post))
+			if verdict.hidden {
+				continue
+			}
+			d.Count++
+			if d.Count > digestMaxPosts {
+				d.More++
+				continue
+			}
+			i, ok := feeds[post.FeedName]
+			if !ok {
+				i = len(d.Feeds)
+				feeds[post.FeedName] = i
+				d.Feeds = append(d.Feeds, digestFeed{Name: post.FeedName})
+			}
+			d.Feeds[i].Posts = append(d.Feeds[i].Posts, digestPost{
+				Title:       post.Title,
+				Url:         post.Url,
+				Author:      post.Author,
+				Published:   s.localTime(post.PublishedAt),
+				Summary:     truncateText(collapseWhitespace(postBody(post.Description, post.Content, false)), 300),
+				Highlighted: verdict.highlighted,
+			})
+		}
+		if len(page) < int(params.RowLimit) {
+			break
+		}
+		params.RowOffset += params.RowLimit
+	}
+	return d, nil
+}
+
+// text renders the plain text part of the digest email.
+func (d digest) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s since %s\n", d.User, pluralize(d.Count, "unread post"), d.Since.Format("Mon Jan 2 15:04"))
+	for _, feed := range d.Feeds {
+		fmt.Fprintf(&b, "\n%s\n", feed.Name)
+		for _, post := range feed.Posts {
+			title := post.Title
+			if post.Highlighted {
+				title += " *"
+			}
+			fmt.Fprintf(&b, "\n  %s\n", title)
+			if post.Author != "" {
+				fmt.Fprintf(&b, "  %s · %s\n", post.Author, post.Published.Format("Mon Jan 2 15:04"))
+			} else {
+				fmt.Fprintf(&b, "  %s\n", post.Published.Format("Mon Jan 2 15:04"))
+			}
+			fmt.Fprintf(&b, "  %s\n", post.Url)
+			if post.Summary != "" {
+				fmt.Fprintf(&b, "%s\n", wrapText(post.Summary, 72, "  "))
+			}
+		}
+	}
+	if d.More > 0 {
+		fmt.Fprintf(&b, "\n…and %d more unread in gator.\n", d.More)
+	}
+	return b.String()
+}
+
+// sendDueDigests sends every scheduled digest whose period has passed.
+// A digest with no unread posts is skipped but still counts as sent, so
+// the next one covers the following period. A digest that fails is logged
+// and tried again after digestRetryDelay, without holding up the others.
+func sendDueDigests(ctx context.Context, s *state) error {
+	schedules, err := s.db.GetDigestSchedules(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, schedule := range schedules {
+		if schedule.LastSentAt.Valid && now.Sub(schedule.LastSentAt.Time) < digestPeriods[schedule.Frequency] {
+			continue
+		}
+		if schedule.LastAttemptAt.Valid && now.Sub(schedule.LastAttemptAt.Time) < digestRetryDelay {
+			continue
+		}
+		if err := sendScheduledDigest(ctx, s, schedule, now); err != nil {
+			fmt.Printf("Digest for %s: %v\n", schedule.UserName, err)
+		}
+	}
+	return nil
+}
+
+func sendScheduledDigest(ctx context.Context, s *state, schedule database.GetDigestSchedulesRow, now time.Time) error {
+	if err := s.db.MarkDigestAttempted(ctx, database.MarkDigestAttemptedParams{UserID: schedule.UserID, LastAttemptAt: sql.NullTime{Time: now, Valid: true}}); err != nil {
+		return err
+	}
+	since := digestSince(database.DigestSchedule{Frequency: schedule.Frequency, LastSentAt: schedule.LastSentAt}, now)
+	d, err := compileDigest(ctx, s, database.User{ID: schedule.UserID, Name: schedule.UserName}, since)
+	if err != nil {
+		return err
+	}
+	if d.Count > 0 {
+		if err := sendDigest(s.cfg.SMTP, schedule.Email, d, now); err != nil {
+			return fmt.Errorf("%w (retrying at %s)", err, s.localTime(now.Add(digestRetryDelay)).Format(time.Kitchen))
+		}
+		fmt.Printf("Sent %s's %s digest to %s\n", schedule.UserName, schedule.Frequency, schedule.Email)
+	}
+	return s.db.MarkDigestSent(ctx, database.MarkDigestSentParams{UserID: schedule.UserID, LastSentAt: sql.NullTime{Time: now, Valid: true}})
+}
+
+func sendDigest(cfg *config.SMTPConfig, to string, d digest, now time.Time) error {
+	if cfg == nil || cfg.Host == "" {
+		return errors.New("no mail server configured: set smtp.host and smtp.from in the config")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid smtp.from %q in config", cfg.From)
+	}
+	var html bytes.Buffer
+	if err := digestHTML.Execute(&html, d); err != nil {
+		return err
+	}
+	subject := "gator digest: " + pluralize(d.Count, "unread post")
+	msg, err := mailMessage(from.String(), to, subject, now, d.text(), html.String())
+	if err != nil {
+		return err
+	}
+	return sendMail(cfg, from.Address, to, msg)
+}
+
+// mailMessage builds a multipart/alternative email with a plain text and
+// an HTML version of the same body.
+func mailMessage(from, to, subject string, date time.Time, text, html string) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := io.WriteString(qp, part.body); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sendMail delivers msg through the configured server, logging in when a
+// username is set.
+func sendMail(cfg *config.SMTPConfig, from, to string, msg []byte) error {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	if !cfg.TLS {
+		return smtp.SendMail(cfg.Addr(), auth, from, []string{to}, msg)
+	}
+	conn, err := tls.Dial("tcp", cfg.Addr(), &tls.Config{ServerName: cfg.Host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
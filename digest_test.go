@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/louiehdev/gatorcli/internal/config"
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+type sentMail struct {
+	from, to string
+	msg      string
+}
+
+// fakeSMTPServer listens on a local port and speaks just enough SMTP to
+// take mail from sendMail, refusing recipients in failFor. It returns a
+// config pointing at it and the messages it has received.
+func fakeSMTPServer(t *testing.T, failFor ...string) (*config.SMTPConfig, func() []sentMail) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	var mu sync.Mutex
+	var sent []sentMail
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				tp := textproto.NewConn(conn)
+				tp.PrintfLine("220 fake ESMTP")
+				var mail sentMail
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+					verb, arg, _ := strings.Cut(line, " ")
+					switch strings.ToUpper(verb) {
+					case "EHLO", "HELO":
+						tp.PrintfLine("250 fake")
+					case "MAIL":
+						mail = sentMail{from: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+						tp.PrintfLine("250 OK")
+					case "RCPT":
+						mail.to = strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+						if slices.Contains(failFor, mail.to) {
+							tp.PrintfLine("550 no such mailbox")
+							continue
+						}
+						tp.PrintfLine("250 OK")
+					case "DATA":
+						tp.PrintfLine("354 go ahead")
+						data, err := tp.ReadDotBytes()
+						if err != nil {
+							return
+						}
+						mail.msg = string(data)
+						mu.Lock()
+						sent = append(sent, mail)
+						mu.Unlock()
+						tp.PrintfLine("250 OK")
+					case "QUIT":
+						tp.PrintfLine("221 bye")
+						return
+					default:
+						tp.PrintfLine("250 OK")
+					}
+				}
+			}()
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.SMTPConfig{Host: host, Port: portNumber, From: "gator@example.com"}
+	return cfg, func() []sentMail {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(sent)
+	}
+}
+
+func scheduleTestDigest(t *testing.T, s *state, user database.User, email string) {
+	t.Helper()
+	now := time.Now().UTC()
+	if _, err := s.db.SetDigestSchedule(context.Background(), database.SetDigestScheduleParams{
+		UserID:    user.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Email:     email,
+		Frequency: "daily",
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSendDueDigests(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	cfg, sent := fakeSMTPServer(t)
+	s.cfg.SMTP = cfg
+
+	user, feed := createTestFeed(t, s, "ann")
+	posts := createTestPosts(t, s, feed, "Unread one", "Already read", "Unread two")
+	if err := s.repo.MarkPostRead(ctx, database.MarkPostReadParams{UserID: user.ID, PostID: posts[1].ID, ReadAt: sql.NullTime{Time: time.Now().UTC(), Valid: true}}); err != nil {
+		t.Fatal(err)
+	}
+	// Digests go by when gator stored a post, so one fetched today with
+	// an old date is still included.
+	now := time.Now().UTC()
+	if _, err := s.repo.CreatePost(ctx, database.CreatePostParams{
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		PublishedAt: now.AddDate(0, 0, -10),
+		Title:       "Late arrival",
+		Url:         feed.Url + "#late",
+		FeedID:      feed.ID,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	scheduleTestDigest(t, s, user, "ann@example.com")
+
+	if err := sendDueDigests(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	mails := sent()
+	if len(mails) != 1 {
+		t.Fatalf("sent %d digests, want 1", len(mails))
+	}
+	mail := mails[0]
+	if mail.from != "gator@example.com" || mail.to != "ann@example.com" {
+		t.Errorf("sent from %s to %s", mail.from, mail.to)
+	}
+	for _, want := range []string{"Subject: gator digest: 3 unread posts", "Unread one", "Unread two", "Late arrival", "ann's feed", "text/html"} {
+		if !strings.Contains(mail.msg, want) {
+			t.Errorf("digest does not contain %q:\n%s", want, mail.msg)
+		}
+	}
+	if strings.Contains(mail.msg, "Already read") {
+		t.Error("digest lists a post that was already read")
+	}
+
+	schedule, err := s.db.GetDigestSchedule(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !schedule.LastSentAt.Valid {
+		t.Error("digest was not marked sent")
+	}
+	if err := sendDueDigests(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent()) != 1 {
+		t.Errorf("sent %d digests, want the daily digest only once", len(sent()))
+	}
+}
+
+func TestSendDueDigestsContinuesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	cfg, sent := fakeSMTPServer(t, "ann@example.com")
+	s.cfg.SMTP = cfg
+
+	ann, annFeed := createTestFeed(t, s, "ann")
+	bob, bobFeed := createTestFeed(t, s, "bob")
+	createTestPosts(t, s, annFeed, "For ann")
+	createTestPosts(t, s, bobFeed, "For bob")
+	scheduleTestDigest(t, s, ann, "ann@example.com")
+	scheduleTestDigest(t, s, bob, "bob@example.com")
+
+	if err := sendDueDigests(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if mails := sent(); len(mails) != 1 || mails[0].to != "bob@example.com" {
+		t.Fatalf("sent %v, want only bob's digest", mails)
+	}
+	schedule, err := s.db.GetDigestSchedule(ctx, ann.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.LastSentAt.Valid || !schedule.LastAttemptAt.Valid {
+		t.Errorf("failed digest: last sent %v, last attempt %v; want only the attempt recorded", schedule.LastSentAt, schedule.LastAttemptAt)
+	}
+
+	// The failed digest waits for digestRetryDelay instead of being
+	// retried on the next tick.
+	cfg, sent = fakeSMTPServer(t)
+	s.cfg.SMTP = cfg
+	if err := sendDueDigests(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent()) != 0 {
+		t.Errorf("sent %d digests right after a failure, want none", len(sent()))
+	}
+}
+
+func TestCompileDigestCountsPostsPastTheCap(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	user, feed := createTestFeed(t, s, "ann")
+	titles := make([]string, digestMaxPosts+5)
+	for idx := range titles {
+		titles[idx] = fmt.Sprintf("Post %d", idx)
+	}
+	createTestPosts(t, s, feed, titles...)
+
+	d, err := compileDigest(ctx, s, user, time.Now().UTC().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Count != len(titles) || d.More != 5 {
+		t.Errorf("digest counts %d posts with %d more, want %d with 5 more", d.Count, d.More, len(titles))
+	}
+	if len(d.Feeds) != 1 || len(d.Feeds[0].Posts) != digestMaxPosts {
+		t.Fatalf("digest lists %v, want %d posts from one feed", d.Feeds, digestMaxPosts)
+	}
+	if first := d.Feeds[0].Posts[0].Title; first != "Post 0" {
+		t.Errorf("digest starts with %q, want the newest post", first)
+	}
+	if text := d.text(); !strings.Contains(text, "105 unread posts") || !strings.Contains(text, "and 5 more unread") {
+		t.Errorf("digest text does not give the full count:\n%s", text)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+const defaultDownloadDir = "~/Downloads"
+
+func storeEnclosures(ctx context.Context, s *state, postID int64, enclosures []enclosure) error {
+	for _, enc := range enclosures {
+		if err := s.db.CreateEnclosure(ctx, database.CreateEnclosureParams{
+			PostID:          postID,
+			Url:             enc.URL,
+			MimeType:        enc.MimeType,
+			Length:          enc.Length,
+			DurationSeconds: sql.NullInt32{Int32: int32(enc.Duration), Valid: enc.Duration > 0},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeEnclosure formats an enclosure as its URL followed by whatever
+// the feed said about its type, size and duration.
+func describeEnclosure(enc database.Enclosure) string {
+	var details []string
+	if enc.MimeType != "" {
+		details = append(details, enc.MimeType)
+	}
+	if enc.Length > 0 {
+		details = append(details, formatSize(enc.Length))
+	}
+	if enc.DurationSeconds.Valid {
+		details = append(details, formatDuration(int(enc.DurationSeconds.Int32)))
+	}
+	if len(details) == 0 {
+		return enc.Url
+	}
+	return enc.Url + " (" + strings.Join(details, ", ") + ")"
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return pluralize(int(n), "byte")
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, next := range []string{"MB", "GB", "TB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+// formatDuration writes seconds as m:ss, or h:mm:ss from an hour up.
+func formatDuration(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// commandDownload saves a post's enclosures to the download directory.
+// Files are written to a .part file first, so an interrupted download is
+// resumed with a range request the next time.
+func commandDownload(s *state, cmd command, user database.User) error {
+	id, err := strconv.ParseInt(strings.TrimPrefix(cmd.arguments[0], "#"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid post id %q", cmd.arguments[0])
+	}
+	ctx := context.Background()
+	if _, err := s.repo.GetPostByID(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no post with id %d", id)
+		}
+		return err
+	}
+	enclosures, err := s.db.GetEnclosuresForPost(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(enclosures) == 0 {
+		return fmt.Errorf("post %d has no enclosures", id)
+	}
+
+	dir := s.cfg.DownloadDir
+	if cmd.flagPassed("dir") {
+		dir = cmd.stringFlag("dir")
+	}
+	if dir == "" {
+		dir = defaultDownloadDir
+	}
+	dir, err = expandHome(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for idx, enc := range enclosures {
+		target := filepath.Join(dir, enclosureFileName(enc.Url, id, idx))
+		if _, err := os.Stat(target); err == nil {
+			fmt.Printf("Already downloaded: %s\n", target)
+			continue
+		}
+		fmt.Printf("Downloading %s\n", describeEnclosure(enc))
+		if err := downloadFile(ctx, enc.Url, target); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %s\n", target)
+	}
+	return nil
+}
+
+// downloadFile fetches url into target via target.part. When the .part
+// file already holds some bytes it asks the server for the rest; servers
+// that ignore the range get a fresh download.
+func downloadFile(ctx context.Context, url, target string) error {
+	partial := target + ".part"
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "gator")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+		fmt.Printf("Resuming at %s\n", formatSize(offset))
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		flags |= os.O_TRUNC
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The .part file already holds the whole file.
+		return os.Rename(partial, target)
+	default:
+		return fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
+	file, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		return fmt.Errorf("downloading %s: %w (run download again to resume)", url, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partial, target)
+}
+
+// enclosureFileName prefixes the file name from the URL's path with the
+// post id and the enclosure's position, so files that share a name, such
+// as every feed's episode.mp3, do not overwrite each other. URLs without a
+// usable name get one built from the post id alone.
+func enclosureFileName(rawURL string, postID int64, idx int) string {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		name := path.Base(parsed.Path)
+		if name != "." && name != "/" && name != ".." && !strings.HasPrefix(name, ".") {
+			return fmt.Sprintf("%d-%d-%s", postID, idx+1, name)
+		}
+	}
+	return fmt.Sprintf("post-%d-%d", postID, idx+1)
+}
+
+func expandHome(dir string) (string, error) {
+	rest, ok := strings.CutPrefix(dir, "~/")
+	if !ok && dir != "~" {
+		return dir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, rest), nil
+}
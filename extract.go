@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+const (
+	articleFetchTimeout = 10 * time.Second
+	maxArticleSize      = 2 << 20
+	articleCacheTTL     = 7 * 24 * time.Hour
+	// articleRetryTTL is how long a failure that may go away by itself,
+	// such as a timeout or a 5xx response, is cached before the page is
+	// tried again.
+	articleRetryTTL = time.Hour
+	// minArticleLength is the shortest extracted text, in characters, that
+	// is still taken for the article rather than page furniture.
+	minArticleLength = 250
+)
+
+var (
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|header|menu|modal|nav|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|popup|promo|ad-break|agegate|pagination|pager`)
+	maybeCandidate    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveClass     = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeClass     = regexp.MustCompile(`(?i)hidden|banner|combx|comment|com-|contact|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// fullTextArticle returns the main content of the page at url, using the
+// article cache when it has a fresh copy. Failures are cached as empty
+// content so a broken page is not fetched again on every scrape: for
+// articleCacheTTL when the page is missing or not HTML, and for
+// articleRetryTTL when it might work next time.
+func fullTextArticle(ctx context.Context, s *state, url string) (string, error) {
+	now := time.Now().UTC()
+	cached, err := s.db.GetCachedArticle(ctx, database.GetCachedArticleParams{Url: url, ExpiresAt: now})
+	if err == nil {
+		return cached.Content, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	content, retry, fetchErr := fetchArticle(ctx, url)
+	ttl := articleCacheTTL
+	if retry {
+		ttl = articleRetryTTL
+	}
+	if err := s.db.CacheArticle(ctx, database.CacheArticleParams{Url: url, Content: content, ExpiresAt: now.Add(ttl)}); err != nil {
+		return "", err
+	}
+	return content, fetchErr
+}
+
+// fetchArticle downloads an HTML page, giving up after articleFetchTimeout
+// or maxArticleSize bytes, and extracts its main content. retry reports
+// whether a failure is worth trying again soon: network errors, timeouts
+// and 5xx or 429 responses are, while other errors are down to the page.
+func fetchArticle(ctx context.Context, url string) (content string, retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, articleFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("User-Agent", "gator")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return "", retry, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", false, fmt.Errorf("fetching %s: not an HTML page (%s)", url, mediaType)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxArticleSize+1))
+	if err != nil {
+		return "", true, err
+	}
+	if len(data) > maxArticleSize {
+		return "", false, fmt.Errorf("fetching %s: page is larger than %d bytes", url, maxArticleSize)
+	}
+	article, err := extractArticle(bytes.NewReader(data))
+	if err != nil {
+		return "", false, err
+	}
+	return resolveHTMLLinks(article, resp.Request.URL), false, nil
+}
+
+// extractArticle finds the element most likely to hold a page's article
+// with the usual readability heuristics: paragraphs score their parent and
+// grandparent by length and comma count, class and id names nudge the
+// score up or down, and link-heavy blocks are penalised. It returns the
+// winner as HTML, or "" when nothing looks like an article.
+func extractArticle(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", err
+	}
+	body := findElement(doc, atom.Body)
+	if body == nil {
+		return "", nil
+	}
+	stripUnlikely(body)
+
+	scores := make(map[*html.Node]float64)
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			scores[node] = tagWeight(node) + classWeight(node)
+		}
+		scores[node] += score
+	}
+	walkElements(body, func(node *html.Node) {
+		if node.DataAtom != atom.P && node.DataAtom != atom.Pre && node.DataAtom != atom.Td {
+			return
+		}
+		text := strings.TrimSpace(nodeText(node))
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		addScore(node.Parent, score)
+		if node.Parent != nil {
+			addScore(node.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for node, score := range scores {
+		score *= 1 - linkDensity(node)
+		if best == nil || score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+	if best == nil || len(strings.TrimSpace(nodeText(best))) < minArticleLength {
+		return "", nil
+	}
+	var out strings.Builder
+	for child := best.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&out, child); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// stripUnlikely removes elements that never hold article text and blocks
+// whose class or id marks them as navigation, comments and the like.
+func stripUnlikely(node *html.Node) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.CommentNode {
+			node.RemoveChild(child)
+		} else if child.Type == html.ElementNode {
+			switch child.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Nav, atom.Header, atom.Footer, atom.Aside, atom.Form, atom.Iframe, atom.Button, atom.Svg:
+				node.RemoveChild(child)
+			default:
+				names := attr(child, "class") + " " + attr(child, "id")
+				if child.DataAtom != atom.Article && child.DataAtom != atom.Body &&
+					unlikelyCandidate.MatchString(names) && !maybeCandidate.MatchString(names) {
+					node.RemoveChild(child)
+				} else {
+					stripUnlikely(child)
+				}
+			}
+		}
+		child = next
+	}
+}
+
+func tagWeight(node *html.Node) float64 {
+	switch node.DataAtom {
+	case atom.Article:
+		return 10
+	case atom.Div, atom.Main, atom.Section:
+		return 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		return 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		return -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		return -5
+	}
+	return 0
+}
+
+func classWeight(node *html.Node) float64 {
+	weight := 0.0
+	for _, name := range []string{attr(node, "class"), attr(node, "id")} {
+		if name == "" {
+			continue
+		}
+		if negativeClass.MatchString(name) {
+			weight -= 25
+		}
+		if positiveClass.MatchString(name) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func linkDensity(node *html.Node) float64 {
+	textLength := len(nodeText(node))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	walkElements(node, func(n *html.Node) {
+		if n.DataAtom == atom.A {
+			linkLength += len(nodeText(n))
+		}
+	})
+	return float64(linkLength) / float64(textLength)
+}
+
+func findElement(node *html.Node, tag atom.Atom) *html.Node {
+	var found *html.Node
+	walkElements(node, func(n *html.Node) {
+		if found == nil && n.DataAtom == tag {
+			found = n
+		}
+	})
+	return found
+}
+
+func walkElements(node *html.Node, visit func(*html.Node)) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode {
+			visit(child)
+			walkElements(child, visit)
+		}
+	}
+}
+
+func nodeText(node *html.Node) string {
+	var b strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(node)
+	return b.String()
+}
+
+func attr(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// commandFullText turns full-text extraction on or off for a feed. Only the
+// user who added the feed may change it.
+func commandFullText(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	feed, err := resolveUserFeed(ctx, s, user, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+	if len(cmd.arguments) == 1 {
+		fmt.Printf("%s: full text %s\n", feed.Name, onOff(feed.FullText))
+		return nil
+	}
+	var enabled bool
+	switch cmd.arguments[1] {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		return fmt.Errorf("expected on or off, got %q", cmd.arguments[1])
+	}
+	if feed.UserID != user.ID {
+		return fmt.Errorf("only the user who added %s can change full-text extraction", feed.Name)
+	}
+	if err := s.db.SetFeedFullText(ctx, database.SetFeedFullTextParams{ID: feed.ID, FullText: enabled, UpdatedAt: time.Now().UTC()}); err != nil {
+		return err
+	}
+	fmt.Printf("%s: full text %s\n", feed.Name, onOff(enabled))
+	return nil
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
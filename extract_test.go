@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+func TestFullTextArticleCachesFailuresByKind(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		ttl  time.Duration
+	}{
+		{"/unavailable", articleRetryTTL},
+		{"/missing", articleCacheTTL},
+		{"/image", articleCacheTTL},
+	}
+	for _, tt := range tests {
+		url := server.URL + tt.path
+		before := time.Now().UTC()
+		if _, err := fullTextArticle(ctx, s, url); err == nil {
+			t.Errorf("fullTextArticle(%s) succeeded, want an error", tt.path)
+		}
+		if _, err := s.db.GetCachedArticle(ctx, database.GetCachedArticleParams{Url: url, ExpiresAt: before.Add(tt.ttl - time.Minute)}); err != nil {
+			t.Errorf("%s: cached for less than %v: %v", tt.path, tt.ttl, err)
+		}
+		if _, err := s.db.GetCachedArticle(ctx, database.GetCachedArticleParams{Url: url, ExpiresAt: before.Add(tt.ttl + time.Minute)}); err == nil {
+			t.Errorf("%s: cached for longer than %v", tt.path, tt.ttl)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+const (
+	filterInclude   = "include"
+	filterExclude   = "exclude"
+	filterHighlight = "highlight"
+)
+
+var filterKinds = []string{"keyword", "regex", "feed", "author"}
+
+// filterRule is a stored rule ready to be matched; regex rules carry their
+// compiled pattern.
+type filterRule struct {
+	database.FilterRule
+	re *regexp.Regexp
+}
+
+// filterPost is the part of a post that rules look at. text is filled in
+// on first use, as it needs the HTML converted.
+type filterPost struct {
+	feedID      int64
+	title       string
+	description string
+	content     string
+	author      string
+	text        string
+}
+
+// filterVerdict is the outcome of a user's rules for one post.
+type filterVerdict struct {
+	hidden      bool
+	highlighted bool
+	matched     []int64
+}
+
+func loadFilterRules(ctx context.Context, s *state, userID int64) ([]filterRule, error) {
+	stored, err := s.db.GetFilterRulesForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]filterRule, 0, len(stored))
+	for _, rule := range stored {
+		compiled, err := compileFilterRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("filter rule %d: %w", rule.ID, err)
+		}
+		rules = append(rules, compiled)
+	}
+	return rules, nil
+}
+
+func compileFilterRule(rule database.FilterRule) (filterRule, error) {
+	compiled := filterRule{FilterRule: rule}
+	if rule.Kind == "regex" {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return filterRule{}, err
+		}
+		compiled.re = re
+	}
+	return compiled, nil
+}
+
+// matches reports whether the rule applies to post. Keywords match the
+// title, summary and article ignoring case, regular expressions match the
+// same text as written, and author rules match part of the author's name.
+func (r filterRule) matches(post *filterPost) bool {
+	switch r.Kind {
+	case "feed":
+		return r.FeedID.Valid && r.FeedID.Int64 == post.feedID
+	case "author":
+		return post.author != "" && strings.Contains(strings.ToLower(post.author), strings.ToLower(r.Pattern))
+	case "keyword":
+		return strings.Contains(strings.ToLower(post.searchText()), strings.ToLower(r.Pattern))
+	case "regex":
+		return r.re != nil && r.re.MatchString(post.searchText())
+	}
+	return false
+}
+
+func (p *filterPost) searchText() string {
+	if p.text == "" {
+		p.text = p.title + "\n" + htmlToText(p.description) + "\n" + htmlToText(p.content)
+	}
+	return p.text
+}
+
+// evaluateFilters applies a user's rules to a post. With any include rules
+// a post must match one of them to be shown; a matching exclude rule hides
+// it either way, and highlight rules only mark it.
+func evaluateFilters(rules []filterRule, post *filterPost) filterVerdict {
+	var verdict filterVerdict
+	hasInclude, included := false, false
+	for _, rule := range rules {
+		if rule.Action == filterInclude {
+			hasInclude = true
+		}
+		if !rule.matches(post) {
+			continue
+		}
+		verdict.matched = append(verdict.matched, rule.ID)
+		switch rule.Action {
+		case filterInclude:
+			included = true
+		case filterExclude:
+			verdict.hidden = true
+		case filterHighlight:
+			verdict.highlighted = true
+		}
+	}
+	if hasInclude && !included {
+		verdict.hidden = true
+	}
+	return verdict
+}
+
+func postForFilters(post database.GetPostsForUserRow) *filterPost {
+	return &filterPost{
+		feedID:      post.FeedID,
+		title:       post.Title,
+		description: post.Description,
+		content:     post.Content,
+		author:      post.Author,
+	}
+}
+
+// filteredPosts pages through the posts matching params, params.RowLimit
+// at a time, until it has params.RowLimit posts that the user's rules do
+// not hide or the posts run out, so filtering does not shorten the listing.
+func filteredPosts(ctx context.Context, s *state, rules []filterRule, params database.GetPostsForUserParams) ([]database.GetPostsForUserRow, map[int64]filterVerdict, error) {
+	limit := int(params.RowLimit)
+	verdicts := make(map[int64]filterVerdict)
+	if len(rules) == 0 {
+		posts, err := s.db.GetPostsForUser(ctx, params)
+		return posts, verdicts, err
+	}
+	var kept []database.GetPostsForUserRow
+	for len(kept) < limit {
+		page, err := s.db.GetPostsForUser(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, post := range page {
+			verdict := evaluateFilters(rules, postForFilters(post))
+			if verdict.hidden {
+				continue
+			}
+			verdicts[post.ID] = verdict
+			kept = append(kept, post)
+			if len(kept) == limit {
+				break
+			}
+		}
+		if len(page) < int(params.RowLimit) {
+			break
+		}
+		params.RowOffset += params.RowLimit
+	}
+	return kept, verdicts, nil
+}
+
+type filterRuleRecord struct {
+	ID      int64  `json:"id"`
+	Action  string `json:"action"`
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+}
+
+type filterTestRecord struct {
+	ID      int64   `json:"id"`
+	Feed    string  `json:"feed_name"`
+	Title   string  `json:"title"`
+	Result  string  `json:"result"`
+	Matched []int64 `json:"matched_rules,omitempty"`
+}
+
+// commandFilter manages the current user's filter rules:
+//
+//	filter add include|exclude|highlight keyword|regex|feed|author <pattern>
+//	filter delete <id>
+//	filter list
+//	filter test [--limit n]
+//
+// test is a dry run: it shows what the rules do to the newest posts.
+func commandFilter(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	action, args := cmd.arguments[0], cmd.arguments[1:]
+	switch action {
+	case "add":
+		if len(args) != 3 {
+			return errors.New("usage: filter add include|exclude|highlight keyword|regex|feed|author <pattern>")
+		}
+		return addFilterRule(ctx, s, user, args[0], args[1], args[2])
+	case "delete":
+		if len(args) != 1 {
+			return errors.New("usage: filter delete <id>")
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rule id %q", args[0])
+		}
+		deleted, err := s.db.DeleteFilterRule(ctx, database.DeleteFilterRuleParams{ID: id, UserID: user.ID})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return fmt.Errorf("no filter rule with id %d", id)
+		}
+		fmt.Printf("Deleted filter rule %d\n", id)
+		return nil
+	case "list":
+		if len(args) != 0 {
+			return errors.New("usage: filter list")
+		}
+		return listFilterRules(ctx, s, user)
+	case "test":
+		if len(args) != 0 {
+			return errors.New("usage: filter test [--limit n]")
+		}
+		return testFilterRules(ctx, s, cmd, user)
+	}
+	return fmt.Errorf("unknown filter action %q (expected %s)", action, strings.Join(filterActions, ", "))
+}
+
+func addFilterRule(ctx context.Context, s *state, user database.User, action, kind, pattern string) error {
+	if !slices.Contains([]string{filterInclude, filterExclude, filterHighlight}, action) {
+		return fmt.Errorf("unknown rule action %q (expected include, exclude or highlight)", action)
+	}
+	if !slices.Contains(filterKinds, kind) {
+		return fmt.Errorf("unknown rule kind %q (expected %s)", kind, strings.Join(filterKinds, ", "))
+	}
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("rule pattern is empty")
+	}
+	params := database.CreateFilterRuleParams{
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Action:    action,
+		Kind:      kind,
+		Pattern:   pattern,
+	}
+	switch kind {
+	case "feed":
+		feed, err := resolveUserFeed(ctx, s, user, pattern)
+		if err != nil {
+			return err
+		}
+		params.FeedID = sql.NullInt64{Int64: feed.ID, Valid: true}
+		params.Pattern = feed.Url
+	case "regex":
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regular expression: %w", err)
+		}
+	}
+	rule, err := s.db.CreateFilterRule(ctx, params)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added filter rule %d: %s\n", rule.ID, describeFilterRule(rule))
+	return nil
+}
+
+func describeFilterRule(rule database.FilterRule) string {
+	return fmt.Sprintf("%s %s %q", rule.Action, rule.Kind, rule.Pattern)
+}
+
+func listFilterRules(ctx context.Context, s *state, user database.User) error {
+	rules, err := s.db.GetFilterRulesForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	records := make([]filterRuleRecord, 0, len(rules))
+	rows := make([][]string, 0, len(rules))
+	for _, rule := range rules {
+		records = append(records, filterRuleRecord{ID: rule.ID, Action: rule.Action, Kind: rule.Kind, Pattern: rule.Pattern})
+		rows = append(rows, []string{strconv.FormatInt(rule.ID, 10), rule.Action, rule.Kind, rule.Pattern})
+	}
+	return s.render(listing{
+		columns: []string{"id", "action", "kind", "pattern"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			if len(rules) == 0 {
+				fmt.Fprintln(w, "No filter rules")
+				return
+			}
+			for _, rule := range rules {
+				fmt.Fprintf(w, "%3d  %s\n", rule.ID, describeFilterRule(rule))
+			}
+		},
+	})
+}
+
+func testFilterRules(ctx context.Context, s *state, cmd command, user database.User) error {
+	rules, err := loadFilterRules(ctx, s, user.ID)
+	if err != nil {
+		return err
+	}
+	posts, err := s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, RowLimit: int32(cmd.intFlag("limit"))})
+	if err != nil {
+		return err
+	}
+	records := make([]filterTestRecord, 0, len(posts))
+	rows := make([][]string, 0, len(posts))
+	for _, post := range posts {
+		verdict := evaluateFilters(rules, postForFilters(post))
+		result := "shown"
+		switch {
+		case verdict.hidden:
+			result = "hidden"
+		case verdict.highlighted:
+			result = "highlighted"
+		}
+		matched := make([]string, 0, len(verdict.matched))
+		for _, id := range verdict.matched {
+			matched = append(matched, strconv.FormatInt(id, 10))
+		}
+		records = append(records, filterTestRecord{ID: post.ID, Feed: post.FeedName, Title: post.Title, Result: result, Matched: verdict.matched})
+		rows = append(rows, []string{strconv.FormatInt(post.ID, 10), post.FeedName, post.Title, result, strings.Join(matched, " ")})
+	}
+	return s.render(listing{
+		columns: []string{"id", "feed_name", "title", "result", "matched_rules"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			for _, row := range rows {
+				line := fmt.Sprintf("%-11s #%s · %s · %s", row[3], row[0], row[1], row[2])
+				if row[4] != "" {
+					line += " (rules " + row[4] + ")"
+				}
+				fmt.Fprintln(w, line)
+			}
+		},
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+func TestFilteredPostsFillsLimitPastExcludedPosts(t *testing.T) {
+	s := newTestState(t)
+	user, feed := createTestFeed(t, s, "ann")
+	createTestPosts(t, s, feed, "one", "skip two", "skip three", "four", "five", "six", "skip seven")
+
+	exclude, err := compileFilterRule(database.FilterRule{ID: 1, UserID: user.ID, Action: filterExclude, Kind: "keyword", Pattern: "skip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := []filterRule{exclude}
+
+	tests := []struct {
+		limit int32
+		want  []string
+	}{
+		{1, []string{"one"}},
+		{2, []string{"one", "four"}},
+		{3, []string{"one", "four", "five"}},
+		{4, []string{"one", "four", "five", "six"}},
+		{10, []string{"one", "four", "five", "six"}},
+	}
+	for _, test := range tests {
+		posts, verdicts, err := filteredPosts(context.Background(), s, rules, database.GetPostsForUserParams{UserID: user.ID, RowLimit: test.limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, post := range posts {
+			titles = append(titles, post.Title)
+			if verdicts[post.ID].hidden {
+				t.Errorf("limit %d: kept post %q is marked hidden", test.limit, post.Title)
+			}
+		}
+		if !slices.Equal(titles, test.want) {
+			t.Errorf("limit %d: got %q, want %q", test.limit, titles, test.want)
+		}
+	}
+}
+
+func TestFilteredPostsSkipsWhollyExcludedPage(t *testing.T) {
+	s := newTestState(t)
+	user, feed := createTestFeed(t, s, "ann")
+	createTestPosts(t, s, feed, "skip one", "skip two", "three", "four")
+
+	exclude, err := compileFilterRule(database.FilterRule{ID: 1, UserID: user.ID, Action: filterExclude, Kind: "keyword", Pattern: "skip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, _, err := filteredPosts(context.Background(), s, []filterRule{exclude}, database.GetPostsForUserParams{UserID: user.ID, RowLimit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[0].Title != "three" || posts[1].Title != "four" {
+		t.Errorf("got %d posts %v, want three and four", len(posts), posts)
+	}
+}
+
+func TestEvaluateFilters(t *testing.T) {
+	rule := func(id int64, action, kind, pattern string) database.FilterRule {
+		return database.FilterRule{ID: id, Action: action, Kind: kind, Pattern: pattern}
+	}
+	feedRule := rule(5, filterExclude, "feed", "https://example.com/ads.xml")
+	feedRule.FeedID = sql.NullInt64{Int64: 7, Valid: true}
+	golang := &filterPost{feedID: 1, title: "Go 1.25 is out", description: "<p>Release notes</p>", author: "The Go Team"}
+	rust := &filterPost{feedID: 1, title: "Rust 2024", content: "<p>Edition notes, <b>sponsored</b></p>", author: "Ferris"}
+	ad := &filterPost{feedID: 7, title: "Buy Go mugs"}
+
+	tests := []struct {
+		name        string
+		rules       []database.FilterRule
+		post        *filterPost
+		hidden      bool
+		highlighted bool
+		matched     []int64
+	}{
+		{"no rules", nil, golang, false, false, nil},
+		{"include only, matching", []database.FilterRule{rule(1, filterInclude, "keyword", "go 1.25")}, golang, false, false, []int64{1}},
+		{"include only, not matching", []database.FilterRule{rule(1, filterInclude, "keyword", "go 1.25")}, rust, true, false, nil},
+		{"exclude overrides include", []database.FilterRule{rule(1, filterInclude, "keyword", "notes"), rule(2, filterExclude, "keyword", "SPONSORED")}, rust, true, false, []int64{1, 2}},
+		{"highlight", []database.FilterRule{rule(3, filterHighlight, "keyword", "release")}, golang, false, true, []int64{3}},
+		{"regex is case sensitive", []database.FilterRule{rule(4, filterHighlight, "regex", `\bgo \d+\.\d+`)}, golang, false, false, nil},
+		{"regex", []database.FilterRule{rule(4, filterHighlight, "regex", `(?i)\bgo \d+\.\d+`)}, golang, false, true, []int64{4}},
+		{"regex matches converted HTML", []database.FilterRule{rule(4, filterExclude, "regex", `notes, sponsored`)}, rust, true, false, []int64{4}},
+		{"author", []database.FilterRule{rule(6, filterExclude, "author", "go team")}, golang, true, false, []int64{6}},
+		{"author of another post", []database.FilterRule{rule(6, filterExclude, "author", "go team")}, rust, false, false, nil},
+		{"feed", []database.FilterRule{feedRule}, ad, true, false, []int64{5}},
+		{"feed of another post", []database.FilterRule{feedRule}, golang, false, false, nil},
+	}
+	for _, test := range tests {
+		var rules []filterRule
+		for _, stored := range test.rules {
+			compiled, err := compileFilterRule(stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rules = append(rules, compiled)
+		}
+		post := *test.post
+		verdict := evaluateFilters(rules, &post)
+		if verdict.hidden != test.hidden || verdict.highlighted != test.highlighted || !slices.Equal(verdict.matched, test.matched) {
+			t.Errorf("%s: got hidden %v, highlighted %v, matched %v; want %v, %v, %v",
+				test.name, verdict.hidden, verdict.highlighted, verdict.matched, test.hidden, test.highlighted, test.matched)
+		}
+	}
+}
+
+func TestReaderAppliesFilters(t *testing.T) {
+	exclude, err := compileFilterRule(database.FilterRule{ID: 1, Action: filterExclude, Kind: "keyword", Pattern: "skip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	highlight, err := compileFilterRule(database.FilterRule{ID: 2, Action: filterHighlight, Kind: "keyword", Pattern: "star"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &reader{rules: []filterRule{exclude, highlight}}
+	r.addPost(readerPost{id: 1, title: "skip me"}, true)
+	r.addPost(readerPost{id: 2, title: "a star"}, true)
+	r.addPost(readerPost{id: 3, title: "skip, but starred"}, false)
+	if len(r.posts) != 2 || r.posts[0].id != 2 || r.posts[1].id != 3 {
+		t.Fatalf("reader lists %+v, want posts 2 and 3", r.posts)
+	}
+	if !r.posts[0].highlighted || !r.posts[1].highlighted {
+		t.Errorf("posts matching a highlight rule are not highlighted: %+v", r.posts)
+	}
+}
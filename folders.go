@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+// commandFolder manages the current user's folders:
+//
+//	folder create <name>
+//	folder rename <name> <new_name>
+//	folder delete <name>
+//	folder move <feed> [name]
+//
+// Deleting a folder keeps its feeds, which become unfiled, and moving a
+// feed without a folder name unfiles it.
+func commandFolder(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	action, args := cmd.arguments[0], cmd.arguments[1:]
+	now := time.Now().UTC()
+	switch action {
+	case "create":
+		if len(args) != 1 {
+			return errors.New("usage: folder create <name>")
+		}
+		name, err := folderName(args[0])
+		if err != nil {
+			return err
+		}
+		if _, err := s.db.CreateFolder(ctx, database.CreateFolderParams{CreatedAt: now, UpdatedAt: now, UserID: user.ID, Name: name}); err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("folder %q already exists", name)
+			}
+			return err
+		}
+		fmt.Printf("Created folder %s\n", name)
+	case "rename":
+		if len(args) != 2 {
+			return errors.New("usage: folder rename <name> <new_name>")
+		}
+		folder, err := getFolder(ctx, s, user, args[0])
+		if err != nil {
+			return err
+		}
+		name, err := folderName(args[1])
+		if err != nil {
+			return err
+		}
+		if err := s.db.RenameFolder(ctx, database.RenameFolderParams{ID: folder.ID, Name: name, UpdatedAt: now}); err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("folder %q already exists", name)
+			}
+			return err
+		}
+		fmt.Printf("Renamed folder %s to %s\n", folder.Name, name)
+	case "delete":
+		if len(args) != 1 {
+			return errors.New("usage: folder delete <name>")
+		}
+		folder, err := getFolder(ctx, s, user, args[0])
+		if err != nil {
+			return err
+		}
+		// A saved search limited to the folder would otherwise lose its
+		// meaning, so those have to be changed or deleted first.
+		searches, err := s.db.GetSavedSearchesForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		var usedBy []string
+		for _, search := range searches {
+			if search.FolderID.Valid && search.FolderID.Int64 == folder.ID {
+				usedBy = append(usedBy, search.Name)
+			}
+		}
+		if len(usedBy) == 1 {
+			return fmt.Errorf("folder %s is used by saved search %s; delete it first", folder.Name, usedBy[0])
+		}
+		if len(usedBy) > 1 {
+			return fmt.Errorf("folder %s is used by saved searches %s; delete them first", folder.Name, strings.Join(usedBy, ", "))
+		}
+		if err := s.db.DeleteFolder(ctx, folder.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted folder %s\n", folder.Name)
+	case "move":
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("usage: folder move <feed_url|feed_name> [name]")
+		}
+		feed, err := resolveUserFeed(ctx, s, user, args[0])
+		if err != nil {
+			return err
+		}
+		var folder database.Folder
+		if len(args) == 2 {
+			if folder, err = getFolder(ctx, s, user, args[1]); err != nil {
+				return err
+			}
+		}
+		return moveFeed(ctx, s, user, feed, folder)
+	default:
+		return fmt.Errorf("unknown folder action %q (expected %s)", action, strings.Join(folderActions, ", "))
+	}
+	return nil
+}
+
+// moveFeed files a followed feed under folder, or unfiles it when folder
+// is the zero value.
+func moveFeed(ctx context.Context, s *state, user database.User, feed database.Feed, folder database.Folder) error {
+	updated, err := s.db.SetFeedFollowFolder(ctx, database.SetFeedFollowFolderParams{
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+		FolderID:  sql.NullInt64{Int64: folder.ID, Valid: folder.ID != 0},
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("you are not following %s", feed.Name)
+	}
+	if folder.ID == 0 {
+		fmt.Printf("Moved %s out of its folder\n", feed.Name)
+	} else {
+		fmt.Printf("Moved %s to %s\n", feed.Name, folder.Name)
+	}
+	return nil
+}
+
+func getFolder(ctx context.Context, s *state, user database.User, name string) (database.Folder, error) {
+	folder, err := s.db.GetFolderByName(ctx, database.GetFolderByNameParams{UserID: user.ID, Name: strings.TrimSpace(name)})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Folder{}, fmt.Errorf("no folder named %q", name)
+	}
+	return folder, err
+}
+
+func folderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("folder name is empty")
+	}
+	return name, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+func (c *commands) commandHelp(s *state, cmd command) error {
+	if len(cmd.arguments) == 0 {
+		c.printUsage(os.Stdout)
+		return nil
+	}
+	spec, ok := c.commandMap[cmd.arguments[0]]
+	if !ok {
+		return c.unknownCommandError(cmd.arguments[0])
+	}
+	spec.printHelp(os.Stdout)
+	return nil
+}
+
+func (c *commands) names() []string {
+	names := make([]string, 0, len(c.commandMap))
+	for name := range c.commandMap {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (c *commands) printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gator [--output json|csv|table|plain] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range c.visibleNames() {
+		fmt.Fprintf(writer, "  %s\t%s\n", name, c.commandMap[name].description)
+	}
+	writer.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "gator help <command>" for details on a command.`)
+}
+
+func (spec commandSpec) printHelp(w io.Writer) {
+	fmt.Fprintf(w, "Usage: gator %s\n\n%s\n", spec.usage, spec.description)
+	fs := spec.flagSet()
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(w, "\nFlags:")
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+}
+
+func (c *commands) unknownCommandError(name string) error {
+	if suggestion := c.suggest(name); suggestion != "" {
+		return fmt.Errorf("unknown command %q, did you mean %q?", name, suggestion)
+	}
+	return fmt.Errorf("unknown command %q, run \"gator help\" for a list of commands", name)
+}
+
+// suggest returns the registered command closest to name, or "" when
+// nothing is close enough to be a plausible typo.
+func (c *commands) suggest(name string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range c.visibleNames() {
+		if strings.HasPrefix(candidate, name) && len(name) >= 2 {
+			return candidate
+		}
+		if distance := editDistance(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/louiehdev/gatorcli/internal/config"
+	database "github.com/louiehdev/gatorcli/internal/database"
+	memstore "github.com/louiehdev/gatorcli/internal/memstore"
+)
+
+// newMemoryState keeps users, feeds, follows and posts in memory, for tests
+// of commands that need nothing else. The config file is written to a
+// temporary home directory.
+func newMemoryState(t *testing.T) *state {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	return &state{cfg: &config.Config{}, repo: memstore.New(), schemaChecked: true, location: time.UTC}
+}
+
+// newTestState opens a migrated SQLite database in a temporary directory,
+// for tests that need the queries beyond the repository.
+func newTestState(t *testing.T) *state {
+	t.Helper()
+	db, queries, inTx, migrator, err := openDatabase("sqlite:" + filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return &state{cfg: &config.Config{}, repo: queries, db: queries, runTx: inTx, migrator: migrator, schemaChecked: true, location: time.UTC}
+}
+
+// createTestFeed registers a user who follows a new feed.
+func createTestFeed(t *testing.T, s *state, userName string) (database.User, database.Feed) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	user, err := s.repo.CreateUser(ctx, database.CreateUserParams{CreatedAt: now, UpdatedAt: now, Name: userName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := s.repo.CreateFeed(ctx, database.CreateFeedParams{
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      userName + "'s feed",
+		Url:       "https://example.com/" + userName + ".xml",
+		UserID:    user.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.repo.CreateFeedFollow(ctx, database.CreateFeedFollowParams{CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID}); err != nil {
+		t.Fatal(err)
+	}
+	return user, feed
+}
+
+// createTestPosts adds a post to feed for each title, newest first, an hour
+// apart.
+func createTestPosts(t *testing.T, s *state, feed database.Feed, titles ...string) []database.Post {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Second)
+	posts := make([]database.Post, 0, len(titles))
+	for idx, title := range titles {
+		post, err := s.repo.CreatePost(context.Background(), database.CreatePostParams{
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			PublishedAt: now.Add(-time.Duration(idx) * time.Hour),
+			Title:       title,
+			Url:         feed.Url + "#" + title,
+			FeedID:      feed.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		posts = append(posts, post)
+	}
+	return posts
+}
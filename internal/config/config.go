@@ -3,7 +3,10 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"time"
 )
 
 const configFileName string = ".gatorconfig.json"
@@ -11,6 +14,61 @@ const configFileName string = ".gatorconfig.json"
 type Config struct {
 	Url      string `json:"db_url"`
 	Username string `json:"current_user_name"`
+	Opener   string `json:"opener,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	// FullContent shows the full article instead of the summary by default.
+	FullContent bool `json:"full_content,omitempty"`
+
+	// Retention applies to feeds without their own settings; zero keeps
+	// posts forever. Unread posts newer than KeepUnreadDays are never pruned;
+	// unset means 30 days and zero or less keeps unread posts forever.
+	RetentionDays     int  `json:"retention_days,omitempty"`
+	RetentionMaxPosts int  `json:"retention_max_posts,omitempty"`
+	KeepUnreadDays    *int `json:"keep_unread_days,omitempty"`
+
+	// DownloadDir is where download saves enclosures, ~/Downloads if unset.
+	DownloadDir string `json:"download_dir,omitempty"`
+
+	// SMTP is the mail server digests are sent through.
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+}
+
+// SMTPConfig describes an outgoing mail server. Without TLS the connection
+// is upgraded with STARTTLS when the server offers it; with TLS it is
+// encrypted from the start, as on port 465.
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from"`
+	TLS      bool   `json:"tls,omitempty"`
+}
+
+// Addr returns the server's host:port, defaulting the port to 465 with
+// TLS and to 587 without.
+func (c *SMTPConfig) Addr() string {
+	port := c.Port
+	if port == 0 {
+		port = 587
+		if c.TLS {
+			port = 465
+		}
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+// Location returns the configured display timezone, falling back to the
+// system's local zone when none is set.
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q in config: %w", c.Timezone, err)
+	}
+	return location, nil
 }
 
 func (c *Config) SetUser(user string) error {
@@ -25,7 +83,11 @@ func (c *Config) SetUser(user string) error {
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(configFilePath, configData, 0644); err != nil {
+	// The file can hold the SMTP password, so only the user may read it.
+	if err := os.WriteFile(configFilePath, configData, 0600); err != nil {
+		return err
+	}
+	if err := os.Chmod(configFilePath, 0600); err != nil {
 		return err
 	}
 
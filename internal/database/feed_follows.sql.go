@@ -7,42 +7,43 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted AS (
-INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, user_id, feed_id)
+INSERT INTO feed_follows (created_at, updated_at, user_id, feed_id)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id, display_name)
 
-SELECT inserted.id, inserted.created_at, inserted.updated_at, inserted.user_id, inserted.feed_id, users.name AS user_name, feeds.name AS feed_name
+SELECT inserted.id, inserted.created_at, inserted.updated_at, inserted.user_id, inserted.feed_id, inserted.folder_id, inserted.display_name, users.name AS user_name, feeds.name AS feed_name
 FROM inserted
 INNER JOIN users ON inserted.user_id = users.id
 INNER JOIN feeds ON inserted.feed_id = feeds.id
 `
 
 type CreateFeedFollowParams struct {
-	ID        int32
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	UserID    int32
-	FeedID    int32
+	UserID    int64
+	FeedID    int64
 }
 
 type CreateFeedFollowRow struct {
-	ID        int32
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    int32
-	FeedID    int32
-	UserName  string
-	FeedName  string
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      int64
+	FeedID      int64
+	FolderID    sql.NullInt64
+	DisplayName sql.NullString
+	UserName    string
+	FeedName    string
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
 	row := q.db.QueryRowContext(ctx, createFeedFollow,
-		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.UserID,
@@ -55,6 +56,8 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
+		&i.DisplayName,
 		&i.UserName,
 		&i.FeedName,
 	)
@@ -67,8 +70,8 @@ WHERE user_id = $1 AND feed_id = $2
 `
 
 type DeleteFeedFollowParams struct {
-	UserID int32
-	FeedID int32
+	UserID int64
+	FeedID int64
 }
 
 func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
@@ -77,22 +80,31 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feeds.name AS feed_name
+SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.folder_id, feed_follows.display_name, COALESCE(feed_follows.display_name, feeds.name) AS feed_name,
+    feeds.url AS feed_url,
+    folders.name AS folder_name
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
 WHERE feed_follows.user_id = $1
+ORDER BY folders.name IS NULL, folders.name, feed_name
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        int32
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    int32
-	FeedID    int32
-	FeedName  string
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      int64
+	FeedID      int64
+	FolderID    sql.NullInt64
+	DisplayName sql.NullString
+	FeedName    string
+	FeedUrl     string
+	FolderName  sql.NullString
 }
 
-func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID int32) ([]GetFeedFollowsForUserRow, error) {
+// Follows come grouped by folder, unfiled feeds last.
+func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID int64) ([]GetFeedFollowsForUserRow, error) {
 	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, userID)
 	if err != nil {
 		return nil, err
@@ -107,7 +119,11 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID int32) ([]Ge
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.FolderID,
+			&i.DisplayName,
 			&i.FeedName,
+			&i.FeedUrl,
+			&i.FolderName,
 		); err != nil {
 			return nil, err
 		}
@@ -121,3 +137,29 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID int32) ([]Ge
 	}
 	return items, nil
 }
+
+const setFeedFollowDisplayName = `-- name: SetFeedFollowDisplayName :execrows
+UPDATE feed_follows
+SET display_name = $3, updated_at = $4
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type SetFeedFollowDisplayNameParams struct {
+	UserID      int64
+	FeedID      int64
+	DisplayName sql.NullString
+	UpdatedAt   time.Time
+}
+
+func (q *Queries) SetFeedFollowDisplayName(ctx context.Context, arg SetFeedFollowDisplayNameParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowDisplayName,
+		arg.UserID,
+		arg.FeedID,
+		arg.DisplayName,
+		arg.UpdatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
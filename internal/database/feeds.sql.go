@@ -12,23 +12,21 @@ import (
 )
 
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+INSERT INTO feeds (created_at, updated_at, name, url, user_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, retention_days, retention_max_posts, full_text
 `
 
 type CreateFeedParams struct {
-	ID        int32
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
 	Url       string
-	UserID    int32
+	UserID    int64
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
 	row := q.db.QueryRowContext(ctx, createFeed,
-		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
@@ -44,12 +42,53 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.RetentionDays,
+		&i.RetentionMaxPosts,
+		&i.FullText,
 	)
 	return i, err
 }
 
+const getAllFeeds = `-- name: GetAllFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, retention_days, retention_max_posts, full_text FROM feeds ORDER BY created_at
+`
+
+func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getAllFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.RetentionDays,
+			&i.RetentionMaxPosts,
+			&i.FullText,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeedFromURL = `-- name: GetFeedFromURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, retention_days, retention_max_posts, full_text FROM feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedFromURL(ctx context.Context, url string) (Feed, error) {
@@ -63,6 +102,9 @@ func (q *Queries) GetFeedFromURL(ctx context.Context, url string) (Feed, error)
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.RetentionDays,
+		&i.RetentionMaxPosts,
+		&i.FullText,
 	)
 	return i, err
 }
@@ -103,8 +145,46 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
+const getFeedsByName = `-- name: GetFeedsByName :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, retention_days, retention_max_posts, full_text FROM feeds WHERE name = $1
+`
+
+func (q *Queries) GetFeedsByName(ctx context.Context, name string) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByName, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.RetentionDays,
+			&i.RetentionMaxPosts,
+			&i.FullText,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, retention_days, retention_max_posts, full_text FROM feeds
 ORDER BY last_fetched_at ASC NULLS FIRST, updated_at ASC
 LIMIT 1
 `
@@ -120,6 +200,9 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.RetentionDays,
+		&i.RetentionMaxPosts,
+		&i.FullText,
 	)
 	return i, err
 }
@@ -131,7 +214,7 @@ WHERE id = $1
 `
 
 type MarkFeedFetchedParams struct {
-	ID            int32
+	ID            int64
 	LastFetchedAt sql.NullTime
 }
 
@@ -139,3 +222,43 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.ID, arg.LastFetchedAt)
 	return err
 }
+
+const setFeedFullText = `-- name: SetFeedFullText :exec
+UPDATE feeds
+SET full_text = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedFullTextParams struct {
+	ID        int64
+	FullText  bool
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedFullText(ctx context.Context, arg SetFeedFullTextParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFullText, arg.ID, arg.FullText, arg.UpdatedAt)
+	return err
+}
+
+const setFeedRetention = `-- name: SetFeedRetention :exec
+UPDATE feeds
+SET retention_days = $2, retention_max_posts = $3, updated_at = $4
+WHERE id = $1
+`
+
+type SetFeedRetentionParams struct {
+	ID                int64
+	RetentionDays     sql.NullInt32
+	RetentionMaxPosts sql.NullInt32
+	UpdatedAt         time.Time
+}
+
+func (q *Queries) SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedRetention,
+		arg.ID,
+		arg.RetentionDays,
+		arg.RetentionMaxPosts,
+		arg.UpdatedAt,
+	)
+	return err
+}
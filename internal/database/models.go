@@ -9,38 +9,135 @@ import (
 	"time"
 )
 
-type Feed struct {
-	ID            int32
+type ArticleCache struct {
+	Url       string
+	Content   string
+	ExpiresAt time.Time
+}
+
+type DigestSchedule struct {
+	UserID        int64
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        int32
-	LastFetchedAt sql.NullTime
+	Email         string
+	Frequency     string
+	LastSentAt    sql.NullTime
+	LastAttemptAt sql.NullTime
+}
+
+type Enclosure struct {
+	ID              int64
+	PostID          int64
+	Url             string
+	MimeType        string
+	Length          int64
+	DurationSeconds sql.NullInt32
+}
+
+type Feed struct {
+	ID                int64
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Name              string
+	Url               string
+	UserID            int64
+	LastFetchedAt     sql.NullTime
+	RetentionDays     sql.NullInt32
+	RetentionMaxPosts sql.NullInt32
+	FullText          bool
 }
 
 type FeedFollow struct {
-	ID        int32
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      int64
+	FeedID      int64
+	FolderID    sql.NullInt64
+	DisplayName sql.NullString
+}
+
+type FilterRule struct {
+	ID        int64
+	CreatedAt time.Time
+	UserID    int64
+	Action    string
+	Kind      string
+	Pattern   string
+	FeedID    sql.NullInt64
+}
+
+type Folder struct {
+	ID        int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	UserID    int32
-	FeedID    int32
+	UserID    int64
+	Name      string
 }
 
 type Post struct {
-	ID          int32
+	ID          int64
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	PublishedAt time.Time
 	Title       string
 	Url         string
 	Description string
-	FeedID      int32
+	FeedID      int64
+	Content     string
+	Author      string
+}
+
+type PostState struct {
+	UserID  int64
+	PostID  int64
+	ReadAt  sql.NullTime
+	Starred bool
+}
+
+type PostTag struct {
+	PostID int64
+	Tag    string
+}
+
+type SavedSearch struct {
+	ID         int64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     int64
+	Name       string
+	Query      string
+	Tag        string
+	FolderID   sql.NullInt64
+	WithinDays sql.NullInt32
 }
 
 type User struct {
-	ID        int32
+	ID        int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
 }
+
+type Webhook struct {
+	ID        int64
+	CreatedAt time.Time
+	UserID    int64
+	FeedID    sql.NullInt64
+	Url       string
+	Secret    string
+}
+
+type WebhookDelivery struct {
+	ID            int64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	WebhookID     int64
+	PostID        int64
+	Payload       string
+	Status        string
+	Attempts      int32
+	NextAttemptAt time.Time
+	ResponseCode  sql.NullInt32
+	LastError     string
+}
@@ -11,25 +11,25 @@ import (
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, published_at, title, url, description, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, published_at, title, url, description, feed_id
+INSERT INTO posts (created_at, updated_at, published_at, title, url, description, feed_id, content, author)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, created_at, updated_at, published_at, title, url, description, feed_id, content, author
 `
 
 type CreatePostParams struct {
-	ID          int32
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	PublishedAt time.Time
 	Title       string
 	Url         string
 	Description string
-	FeedID      int32
+	FeedID      int64
+	Content     string
+	Author      string
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
 	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.PublishedAt,
@@ -37,6 +37,8 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Url,
 		arg.Description,
 		arg.FeedID,
+		arg.Content,
+		arg.Author,
 	)
 	var i Post
 	err := row.Scan(
@@ -48,34 +50,130 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Url,
 		&i.Description,
 		&i.FeedID,
+		&i.Content,
+		&i.Author,
+	)
+	return i, err
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT posts.id, posts.created_at, posts.updated_at, posts.published_at, posts.title, posts.url, posts.description, posts.feed_id, posts.content, posts.author, feeds.name AS feed_name
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE posts.id = $1
+`
+
+type GetPostByIDRow struct {
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	PublishedAt time.Time
+	Title       string
+	Url         string
+	Description string
+	FeedID      int64
+	Content     string
+	Author      string
+	FeedName    string
+}
+
+func (q *Queries) GetPostByID(ctx context.Context, id int64) (GetPostByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
+	var i GetPostByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PublishedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.FeedID,
+		&i.Content,
+		&i.Author,
+		&i.FeedName,
 	)
 	return i, err
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT id, created_at, updated_at, published_at, title, url, description, feed_id FROM posts
-WHERE feed_id IN (
-    SELECT feed_id FROM feed_follows
-    WHERE user_id = $1
-)
-ORDER BY published_at DESC
-LIMIT $2
+SELECT posts.id, posts.created_at, posts.updated_at, posts.published_at, posts.title, posts.url, posts.description, posts.feed_id, posts.content, posts.author, COALESCE(feed_follows.display_name, feeds.name) AS feed_name
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+AND (feed_follows.folder_id = $2 OR $2 = 0)
+AND ($3 = '' OR EXISTS (
+    SELECT 1 FROM post_tags
+    WHERE post_tags.post_id = posts.id
+    AND lower(post_tags.tag) = lower($3)
+))
+AND ($4 = ''
+    OR lower(posts.title) LIKE $4 ESCAPE '\'
+    OR lower(posts.description) LIKE $4 ESCAPE '\'
+    OR lower(posts.content) LIKE $4 ESCAPE '\')
+AND posts.published_at >= $5
+AND posts.created_at >= $6
+AND (NOT $7 OR NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.user_id = $1
+    AND post_states.read_at IS NOT NULL
+))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT $8 OFFSET $9
 `
 
 type GetPostsForUserParams struct {
-	UserID int32
-	Limit  int32
+	UserID         int64
+	FolderID       int64
+	Tag            string
+	Pattern        string
+	PublishedAfter time.Time
+	CreatedAfter   time.Time
+	UnreadOnly     bool
+	RowLimit       int32
+	RowOffset      int32
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+type GetPostsForUserRow struct {
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	PublishedAt time.Time
+	Title       string
+	Url         string
+	Description string
+	FeedID      int64
+	Content     string
+	Author      string
+	FeedName    string
+}
+
+// A folder_id of 0 and an empty tag or pattern match every post. pattern
+// is a lowercase LIKE pattern matched against the title, description and
+// content. created_after matches on when gator stored the post rather
+// than when it was published. unread_only leaves out posts the user has
+// read.
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.FolderID,
+		arg.Tag,
+		arg.Pattern,
+		arg.PublishedAfter,
+		arg.CreatedAfter,
+		arg.UnreadOnly,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []GetPostsForUserRow
 	for rows.Next() {
-		var i Post
+		var i GetPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -85,6 +183,9 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Url,
 			&i.Description,
 			&i.FeedID,
+			&i.Content,
+			&i.Author,
+			&i.FeedName,
 		); err != nil {
 			return nil, err
 		}
@@ -98,3 +199,67 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const postExists = `-- name: PostExists :one
+SELECT EXISTS (SELECT 1 FROM posts WHERE url = $1)
+`
+
+func (q *Queries) PostExists(ctx context.Context, url string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, postExists, url)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const prunePosts = `-- name: PrunePosts :execrows
+DELETE FROM posts
+WHERE posts.id IN (
+    SELECT ranked.id FROM (
+        SELECT id, GREATEST(published_at, created_at) AS dated_at,
+            ROW_NUMBER() OVER (ORDER BY published_at DESC, id DESC) AS position
+        FROM posts
+        WHERE feed_id = $1
+    ) AS ranked
+    WHERE (ranked.dated_at < $2
+        OR ($3::bigint > 0 AND ranked.position > $3::bigint))
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = ranked.id AND post_states.starred
+    )
+    AND NOT (ranked.dated_at >= $4 AND EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = $1
+        AND NOT EXISTS (
+            SELECT 1 FROM post_states
+            WHERE post_states.post_id = ranked.id
+            AND post_states.user_id = feed_follows.user_id
+            AND post_states.read_at IS NOT NULL
+        )
+    ))
+)
+`
+
+type PrunePostsParams struct {
+	FeedID          int64
+	PublishedBefore time.Time
+	MaxPosts        int64
+	UnreadSince     time.Time
+}
+
+// Deletes a feed's posts that are older than published_before or ranked
+// past max_posts (0 for no limit), except starred posts and posts newer
+// than unread_since that a follower has not read yet. A post's age is the
+// later of its publication and when it was stored, so a post with a
+// missing or bogus date is not deleted as soon as it is fetched.
+func (q *Queries) PrunePosts(ctx context.Context, arg PrunePostsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, prunePosts,
+		arg.FeedID,
+		arg.PublishedBefore,
+		arg.MaxPosts,
+		arg.UnreadSince,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -11,30 +11,23 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (created_at, updated_at, name)
 VALUES (
     $1,
     $2,
-    $3,
-    $4
+    $3
 )
 RETURNING id, created_at, updated_at, name
 `
 
 type CreateUserParams struct {
-	ID        int32
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Name,
-	)
+	row := q.db.QueryRowContext(ctx, createUser, arg.CreatedAt, arg.UpdatedAt, arg.Name)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -65,7 +58,7 @@ const getUserFromID = `-- name: GetUserFromID :one
 SELECT id, created_at, updated_at, name FROM users WHERE id = $1
 `
 
-func (q *Queries) GetUserFromID(ctx context.Context, id int32) (User, error) {
+func (q *Queries) GetUserFromID(ctx context.Context, id int64) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUserFromID, id)
 	var i User
 	err := row.Scan(
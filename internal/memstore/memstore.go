@@ -0,0 +1,449 @@
+// Package memstore keeps the repository the core commands use (users,
+// feeds, follows and posts) in memory, for tests that do not need a
+// database. It mirrors the constraints of the SQL schema that the commands
+// rely on: unique names and URLs and sql.ErrNoRows for missing rows.
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+// ErrUniqueViolation is returned where the SQL schema would reject a
+// duplicate row.
+var ErrUniqueViolation = errors.New("memstore: unique constraint violated")
+
+type postStateKey struct {
+	userID int64
+	postID int64
+}
+
+type Store struct {
+	mu          sync.Mutex
+	users       map[int64]database.User
+	feeds       map[int64]database.Feed
+	feedFollows map[int64]database.FeedFollow
+	posts       map[int64]database.Post
+	postStates  map[postStateKey]database.PostState
+	lastID      int64
+}
+
+func New() *Store {
+	return &Store{
+		users:       make(map[int64]database.User),
+		feeds:       make(map[int64]database.Feed),
+		feedFollows: make(map[int64]database.FeedFollow),
+		posts:       make(map[int64]database.Post),
+		postStates:  make(map[postStateKey]database.PostState),
+	}
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Name == arg.Name {
+			return database.User{}, ErrUniqueViolation
+		}
+	}
+	user := database.User{ID: s.nextID(), CreatedAt: arg.CreatedAt, UpdatedAt: arg.UpdatedAt, Name: arg.Name}
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *Store) GetUser(ctx context.Context, name string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUserFromID(ctx context.Context, id int64) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (s *Store) GetUsers(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for _, user := range s.users {
+		names = append(names, user.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ResetUsers deletes every user and, through the cascades, everything else.
+func (s *Store) ResetUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.users)
+	clear(s.feeds)
+	clear(s.feedFollows)
+	clear(s.posts)
+	clear(s.postStates)
+	return nil
+}
+
+func (s *Store) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Feed{}, errors.New("memstore: feed references a missing user")
+	}
+	for _, feed := range s.feeds {
+		if feed.Url == arg.Url {
+			return database.Feed{}, ErrUniqueViolation
+		}
+	}
+	feed := database.Feed{
+		ID:        s.nextID(),
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		Url:       arg.Url,
+		UserID:    arg.UserID,
+	}
+	s.feeds[feed.ID] = feed
+	return feed, nil
+}
+
+func (s *Store) GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetFeedsRow
+	for _, feed := range s.sortedFeeds() {
+		rows = append(rows, database.GetFeedsRow{Name: feed.Name, Url: feed.Url, UserName: s.users[feed.UserID].Name})
+	}
+	return rows, nil
+}
+
+func (s *Store) GetAllFeeds(ctx context.Context) ([]database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedFeeds(), nil
+}
+
+func (s *Store) GetFeedFromURL(ctx context.Context, url string) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, feed := range s.feeds {
+		if feed.Url == url {
+			return feed, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (s *Store) GetFeedsByName(ctx context.Context, name string) ([]database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var feeds []database.Feed
+	for _, feed := range s.sortedFeeds() {
+		if feed.Name == name {
+			feeds = append(feeds, feed)
+		}
+	}
+	return feeds, nil
+}
+
+// GetNextFeedToFetch orders like the SQL query: never-fetched feeds first,
+// then the least recently fetched, then the least recently updated.
+func (s *Store) GetNextFeedToFetch(ctx context.Context) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	feeds := s.sortedFeeds()
+	if len(feeds) == 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	sort.SliceStable(feeds, func(i, j int) bool {
+		a, b := feeds[i], feeds[j]
+		if a.LastFetchedAt.Valid != b.LastFetchedAt.Valid {
+			return !a.LastFetchedAt.Valid
+		}
+		if a.LastFetchedAt.Valid && !a.LastFetchedAt.Time.Equal(b.LastFetchedAt.Time) {
+			return a.LastFetchedAt.Time.Before(b.LastFetchedAt.Time)
+		}
+		return a.UpdatedAt.Before(b.UpdatedAt)
+	})
+	return feeds[0], nil
+}
+
+func (s *Store) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	feed, ok := s.feeds[arg.ID]
+	if !ok {
+		return nil
+	}
+	feed.LastFetchedAt = arg.LastFetchedAt
+	feed.UpdatedAt = arg.LastFetchedAt.Time
+	s.feeds[feed.ID] = feed
+	return nil
+}
+
+func (s *Store) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, userOK := s.users[arg.UserID]
+	feed, feedOK := s.feeds[arg.FeedID]
+	if !userOK || !feedOK {
+		return database.CreateFeedFollowRow{}, errors.New("memstore: feed follow references a missing user or feed")
+	}
+	for _, follow := range s.feedFollows {
+		if follow.UserID == arg.UserID && follow.FeedID == arg.FeedID {
+			return database.CreateFeedFollowRow{}, ErrUniqueViolation
+		}
+	}
+	follow := database.FeedFollow{
+		ID:        s.nextID(),
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+	}
+	s.feedFollows[follow.ID] = follow
+	return database.CreateFeedFollowRow{
+		ID:          follow.ID,
+		CreatedAt:   follow.CreatedAt,
+		UpdatedAt:   follow.UpdatedAt,
+		UserID:      follow.UserID,
+		FeedID:      follow.FeedID,
+		FolderID:    follow.FolderID,
+		DisplayName: follow.DisplayName,
+		UserName:    user.Name,
+		FeedName:    feed.Name,
+	}, nil
+}
+
+// GetFeedFollowsForUser orders by name; the store has no folders, so no
+// follow is filed in one.
+func (s *Store) GetFeedFollowsForUser(ctx context.Context, userID int64) ([]database.GetFeedFollowsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetFeedFollowsForUserRow
+	for _, follow := range s.sortedFollows() {
+		if follow.UserID != userID {
+			continue
+		}
+		feed := s.feeds[follow.FeedID]
+		row := database.GetFeedFollowsForUserRow{
+			ID:          follow.ID,
+			CreatedAt:   follow.CreatedAt,
+			UpdatedAt:   follow.UpdatedAt,
+			UserID:      follow.UserID,
+			FeedID:      follow.FeedID,
+			FolderID:    follow.FolderID,
+			DisplayName: follow.DisplayName,
+			FeedName:    s.feedName(userID, feed),
+			FeedUrl:     feed.Url,
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].FeedName < rows[j].FeedName })
+	return rows, nil
+}
+
+func (s *Store) DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, follow := range s.feedFollows {
+		if follow.UserID == arg.UserID && follow.FeedID == arg.FeedID {
+			delete(s.feedFollows, id)
+		}
+	}
+	return nil
+}
+
+func (s *Store) SetFeedFollowDisplayName(ctx context.Context, arg database.SetFeedFollowDisplayNameParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var updated int64
+	for id, follow := range s.feedFollows {
+		if follow.UserID == arg.UserID && follow.FeedID == arg.FeedID {
+			follow.DisplayName = arg.DisplayName
+			follow.UpdatedAt = arg.UpdatedAt
+			s.feedFollows[id] = follow
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+func (s *Store) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.feeds[arg.FeedID]; !ok {
+		return database.Post{}, errors.New("memstore: post references a missing feed")
+	}
+	for _, post := range s.posts {
+		if post.Url == arg.Url {
+			return database.Post{}, ErrUniqueViolation
+		}
+	}
+	post := database.Post{
+		ID:          s.nextID(),
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		PublishedAt: arg.PublishedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		Content:     arg.Content,
+		Author:      arg.Author,
+		FeedID:      arg.FeedID,
+	}
+	s.posts[post.ID] = post
+	return post, nil
+}
+
+func (s *Store) GetPostByID(ctx context.Context, id int64) (database.GetPostByIDRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	post, ok := s.posts[id]
+	if !ok {
+		return database.GetPostByIDRow{}, sql.ErrNoRows
+	}
+	return database.GetPostByIDRow{
+		ID:          post.ID,
+		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
+		PublishedAt: post.PublishedAt,
+		Title:       post.Title,
+		Url:         post.Url,
+		Description: post.Description,
+		Content:     post.Content,
+		Author:      post.Author,
+		FeedID:      post.FeedID,
+		FeedName:    s.feeds[post.FeedID].Name,
+	}, nil
+}
+
+func (s *Store) GetPostsForUserFeed(ctx context.Context, arg database.GetPostsForUserFeedParams) ([]database.GetPostsForUserFeedRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetPostsForUserFeedRow
+	for _, post := range s.sortedPosts() {
+		if post.FeedID != arg.FeedID {
+			continue
+		}
+		if len(rows) == int(arg.Limit) {
+			break
+		}
+		postState := s.postStates[postStateKey{arg.UserID, post.ID}]
+		rows = append(rows, database.GetPostsForUserFeedRow{
+			ID:          post.ID,
+			CreatedAt:   post.CreatedAt,
+			UpdatedAt:   post.UpdatedAt,
+			PublishedAt: post.PublishedAt,
+			Title:       post.Title,
+			Url:         post.Url,
+			Description: post.Description,
+			Content:     post.Content,
+			Author:      post.Author,
+			FeedID:      post.FeedID,
+			ReadAt:      postState.ReadAt,
+			Starred:     postState.Starred,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := postStateKey{arg.UserID, arg.PostID}
+	postState := s.postStates[key]
+	postState.UserID, postState.PostID = arg.UserID, arg.PostID
+	if !postState.ReadAt.Valid {
+		postState.ReadAt = arg.ReadAt
+	}
+	s.postStates[key] = postState
+	return nil
+}
+
+func (s *Store) PostExists(ctx context.Context, url string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, post := range s.posts {
+		if post.Url == url {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Store) SetPostStarred(ctx context.Context, arg database.SetPostStarredParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := postStateKey{arg.UserID, arg.PostID}
+	postState := s.postStates[key]
+	postState.UserID, postState.PostID = arg.UserID, arg.PostID
+	postState.Starred = arg.Starred
+	s.postStates[key] = postState
+	return nil
+}
+
+// The helpers below expect s.mu to be held.
+
+// nextID hands out ids the way an identity column would. One counter is
+// shared by every table, which keeps ids unique without mirroring the
+// per-table sequences.
+func (s *Store) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
+
+// feedName is the user's display name for a followed feed, falling back to
+// the feed's own name like COALESCE(feed_follows.display_name, feeds.name).
+func (s *Store) feedName(userID int64, feed database.Feed) string {
+	for _, follow := range s.feedFollows {
+		if follow.UserID == userID && follow.FeedID == feed.ID && follow.DisplayName.Valid {
+			return follow.DisplayName.String
+		}
+	}
+	return feed.Name
+}
+
+func (s *Store) sortedFeeds() []database.Feed {
+	feeds := make([]database.Feed, 0, len(s.feeds))
+	for _, feed := range s.feeds {
+		feeds = append(feeds, feed)
+	}
+	sort.Slice(feeds, func(i, j int) bool { return feeds[i].CreatedAt.Before(feeds[j].CreatedAt) })
+	return feeds
+}
+
+func (s *Store) sortedFollows() []database.FeedFollow {
+	follows := make([]database.FeedFollow, 0, len(s.feedFollows))
+	for _, follow := range s.feedFollows {
+		follows = append(follows, follow)
+	}
+	sort.Slice(follows, func(i, j int) bool { return follows[i].CreatedAt.Before(follows[j].CreatedAt) })
+	return follows
+}
+
+// sortedPosts returns posts newest first, like ORDER BY published_at DESC.
+func (s *Store) sortedPosts() []database.Post {
+	posts := make([]database.Post, 0, len(s.posts))
+	for _, post := range s.posts {
+		posts = append(posts, post)
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].PublishedAt.After(posts[j].PublishedAt) })
+	return posts
+}
@@ -0,0 +1,322 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionTable matches the table goose maintains, so databases migrated
+// with the goose CLI are recognised and vice versa.
+const versionTable = "goose_db_version"
+
+// Dialect selects the SQL used for the version table.
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	SQLite
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// String names the migration after its file, e.g. 001_users.
+func (m Migration) String() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+type Status struct {
+	Migration Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New loads the goose-annotated .sql files at the root of fsys. Files are
+// named <version>_<name>.sql.
+func New(db *sql.DB, fsys fs.FS, dialect Dialect) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	seen := make(map[int64]string)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		versionPart, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(versionPart, 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", entry.Name(), versionPart)
+		}
+		if other, exists := seen[version]; exists {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		up, down, err := parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, Up: up, Down: down})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// parse splits a goose migration into its Up and Down statements. Statements
+// end with a semicolon at the end of a line unless they are wrapped in
+// StatementBegin/StatementEnd annotations.
+func parse(source string) ([]string, []string, error) {
+	var up, down []string
+	var current *[]string
+	annotated := false
+	var statement strings.Builder
+	inBlock := false
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "-- +goose") {
+			switch strings.TrimSpace(strings.TrimPrefix(trimmed, "-- +goose")) {
+			case "Up":
+				current, annotated = &up, true
+			case "Down":
+				current = &down
+			case "StatementBegin":
+				inBlock = true
+			case "StatementEnd":
+				inBlock = false
+				if current != nil && strings.TrimSpace(statement.String()) != "" {
+					*current = append(*current, strings.TrimSpace(statement.String()))
+				}
+				statement.Reset()
+			}
+			continue
+		}
+		if current == nil || (!inBlock && (trimmed == "" || strings.HasPrefix(trimmed, "--"))) {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			*current = append(*current, strings.TrimSpace(statement.String()))
+			statement.Reset()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if inBlock {
+		return nil, nil, fmt.Errorf("missing -- +goose StatementEnd")
+	}
+	if current != nil && strings.TrimSpace(statement.String()) != "" {
+		*current = append(*current, strings.TrimSpace(statement.String()))
+	}
+	if !annotated {
+		return nil, nil, fmt.Errorf("no -- +goose Up annotation")
+	}
+	return up, down, nil
+}
+
+// Latest returns the highest version known to this build.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// versionTableExists reports whether the version table exists in the
+// current schema, without creating it.
+func (m *Migrator) versionTableExists(ctx context.Context) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1)"
+	if m.dialect == SQLite {
+		query = "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = $1)"
+	}
+	var exists bool
+	err := m.db.QueryRowContext(ctx, query, versionTable).Scan(&exists)
+	return exists, err
+}
+
+// ensureVersionTable creates the version table before the first migration
+// is applied.
+func (m *Migrator) ensureVersionTable(ctx context.Context) error {
+	createQuery := `CREATE TABLE ` + versionTable + ` (
+    id SERIAL PRIMARY KEY,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP DEFAULT now()
+)`
+	if m.dialect == SQLite {
+		createQuery = `CREATE TABLE ` + versionTable + ` (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id INTEGER NOT NULL,
+    is_applied INTEGER NOT NULL,
+    tstamp TIMESTAMP DEFAULT (datetime('now'))
+)`
+	}
+
+	exists, err := m.versionTableExists(ctx)
+	if err != nil || exists {
+		return err
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, createQuery); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO "+versionTable+" (version_id, is_applied) VALUES (0, TRUE)"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applied returns when each applied version was applied. The newest row for
+// a version wins, mirroring goose. A database without a version table has
+// nothing applied; reading it never creates the table.
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+	exists, err := m.versionTableExists(ctx)
+	if err != nil || !exists {
+		return applied, err
+	}
+	rows, err := m.db.QueryContext(ctx, "SELECT version_id, is_applied, tstamp FROM "+versionTable+" ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	seen := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var tstamp sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &tstamp); err != nil {
+			return nil, err
+		}
+		if seen[version] {
+			continue
+		}
+		seen[version] = true
+		if isApplied && version > 0 {
+			applied[version] = tstamp.Time
+		}
+	}
+	return applied, rows.Err()
+}
+
+// Current returns the highest applied version, or 0 for an empty database.
+func (m *Migrator) Current(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var current int64
+	for version := range applied {
+		current = max(current, version)
+	}
+	return current, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.apply(ctx, migration, migration.Up, true); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	current, err := m.Current(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	if current == 0 {
+		return Migration{}, fmt.Errorf("no migrations to roll back")
+	}
+	for _, migration := range m.migrations {
+		if migration.Version == current {
+			return migration, m.apply(ctx, migration, migration.Down, false)
+		}
+	}
+	return Migration{}, fmt.Errorf("applied version %d is not known to this build", current)
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration, statements []string, up bool) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %s: %w", migration, err)
+		}
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO "+versionTable+" (version_id, is_applied) VALUES ($1, TRUE)", migration.Version)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM "+versionTable+" WHERE version_id = $1", migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
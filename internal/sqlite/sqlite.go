@@ -0,0 +1,149 @@
+// Package sqlite lets the sqlc-generated Postgres queries run on SQLite.
+// Most queries are portable as written; the few that use Postgres-only
+// syntax have a SQLite version with the same "-- name:" in sql/sqlite/queries,
+// which DB substitutes at call time.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// IsURL reports whether a db_url selects the SQLite backend, e.g.
+// "sqlite://~/.gator.db" or "sqlite:gator.db".
+func IsURL(url string) bool {
+	return strings.HasPrefix(url, "sqlite:")
+}
+
+// Open opens the database file named by a sqlite: URL, creating it and its
+// directory if needed.
+func Open(url string) (*sql.DB, error) {
+	path := strings.TrimPrefix(strings.TrimPrefix(url, "sqlite:"), "//")
+	if path == "" {
+		return nil, fmt.Errorf("sqlite URL %q has no file path", url)
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(homeDir, rest)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; sharing one connection avoids
+	// SQLITE_BUSY between concurrent statements in the same process.
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// IsUniqueViolation reports whether err is a SQLite UNIQUE or PRIMARY KEY
+// constraint failure.
+func IsUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+type dbtx interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// DB implements database.DBTX on top of a SQLite connection.
+type DB struct {
+	db      dbtx
+	queries map[string]string
+}
+
+// Wrap loads the SQLite query overrides from the .sql files at the root of
+// queries.
+func Wrap(db dbtx, queries fs.FS) (*DB, error) {
+	entries, err := fs.ReadDir(queries, ".")
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		data, err := fs.ReadFile(queries, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		for _, query := range strings.SplitAfter(string(data), ";") {
+			query = strings.TrimSpace(query)
+			name := queryName(query)
+			if name == "" {
+				continue
+			}
+			if _, exists := overrides[name]; exists {
+				return nil, fmt.Errorf("%s: query %s defined twice", entry.Name(), name)
+			}
+			overrides[name] = strings.TrimSuffix(query, ";")
+		}
+	}
+	return &DB{db: db, queries: overrides}, nil
+}
+
+// queryName extracts X from a query starting with "-- name: X :kind".
+func queryName(query string) string {
+	header, _, _ := strings.Cut(query, "\n")
+	header, ok := strings.CutPrefix(header, "-- name:")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// WithTx returns a DB that runs the same overrides inside tx.
+func (d *DB) WithTx(tx *sql.Tx) *DB {
+	return &DB{db: tx, queries: d.queries}
+}
+
+func (d *DB) rewrite(query string) string {
+	if override, ok := d.queries[queryName(query)]; ok {
+		return override
+	}
+	return query
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.db.ExecContext(ctx, d.rewrite(query), args...)
+}
+
+func (d *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.db.PrepareContext(ctx, d.rewrite(query))
+}
+
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.db.QueryContext(ctx, d.rewrite(query), args...)
+}
+
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return d.db.QueryRowContext(ctx, d.rewrite(query), args...)
+}
@@ -1,51 +1,375 @@
 package main
 
 import (
-	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 
-	_ "github.com/lib/pq"
-
 	config "github.com/louiehdev/gatorcli/internal/config"
-
-	database "github.com/louiehdev/gatorcli/internal/database"
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run executes the command line and returns the exit status. Returning
+// rather than exiting lets the deferred Close run, which SQLite needs to
+// checkpoint its write-ahead log.
+func run() int {
 	Config, err := config.Read()
 	if err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		return 1
 	}
-	db, err := sql.Open("postgres", Config.Url)
+	location, err := Config.Location()
 	if err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		return 1
 	}
-	dbQueries := database.New(db)
+	db, dbQueries, inTx, migrator, err := openDatabase(Config.Url)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	defer db.Close()
 
-	appState := state{cfg: &Config, db: dbQueries}
+	format, args, err := parseGlobalOptions(os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	appState := state{cfg: &Config, repo: dbQueries, db: dbQueries, runTx: inTx, migrator: migrator, output: format, location: location}
 
-	commands := commands{commandMap: make(map[string]commandHandler)}
-	commands.register("login", commandLogin)
-	commands.register("register", commandRegister)
-	commands.register("users", commandUsers)
-	commands.register("reset", commandReset)
-	commands.register("agg", commandAgg)
-	commands.register("addfeed", middlewareLoggedIn(commandAddFeed))
-	commands.register("feeds", commandFeeds)
-	commands.register("follow", middlewareLoggedIn(commandFollow))
-	commands.register("following", middlewareLoggedIn(commandFollowing))
-	commands.register("unfollow", middlewareLoggedIn(commandUnfollow))
-	commands.register("browse", middlewareLoggedIn(commandBrowse))
-	args := os.Args
-	if len(args) < 2 {
-		fmt.Println("Error: not enough arguments provided")
-		os.Exit(1)
-	}
-	cmd := command{name: args[1], arguments: args[2:]}
+	commands := commands{commandMap: make(map[string]commandSpec)}
+	registerCommands(&commands)
+	if len(args) < 1 {
+		commands.printUsage(os.Stderr)
+		return 1
+	}
+	cmd := command{name: args[0], arguments: args[1:]}
 	if err := commands.run(&appState, cmd); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		return 1
+	}
+	return 0
+}
+
+func registerCommands(c *commands) {
+	specs := []commandSpec{
+		{
+			name:            "help",
+			usage:           "help [command]",
+			description:     "Show available commands or details for one command",
+			maxArgs:         1,
+			args:            []argKind{argCommand},
+			skipSchemaCheck: true,
+			handler:         c.commandHelp,
+		},
+		{
+			name:            "completion",
+			usage:           "completion bash|zsh|fish",
+			description:     "Print a shell completion script",
+			minArgs:         1,
+			maxArgs:         1,
+			args:            []argKind{argShell},
+			skipSchemaCheck: true,
+			handler:         c.commandCompletion,
+		},
+		{
+			name:            "__complete",
+			usage:           "__complete <kind>",
+			description:     "Print completion candidates (used by completion scripts)",
+			minArgs:         1,
+			maxArgs:         1,
+			hidden:          true,
+			skipSchemaCheck: true,
+			handler:         c.commandComplete,
+		},
+		{
+			name:            "shell",
+			usage:           "shell",
+			description:     "Start an interactive gator shell",
+			skipSchemaCheck: true,
+			handler:         c.commandShell,
+		},
+		{
+			name:            "migrate",
+			usage:           "migrate up|down|status",
+			description:     "Apply, roll back or list database schema migrations",
+			minArgs:         1,
+			maxArgs:         1,
+			args:            []argKind{argMigrateAction},
+			skipSchemaCheck: true,
+			listing:         true,
+			handler:         commandMigrate,
+		},
+		{
+			name:        "login",
+			usage:       "login <user_name>",
+			description: "Switch the current user",
+			minArgs:     1,
+			maxArgs:     1,
+			args:        []argKind{argUser},
+			handler:     commandLogin,
+		},
+		{
+			name:        "register",
+			usage:       "register <user_name>",
+			description: "Create a new user and log in as them",
+			minArgs:     1,
+			maxArgs:     1,
+			handler:     commandRegister,
+		},
+		{
+			name:        "users",
+			usage:       "users",
+			description: "List registered users",
+			listing:     true,
+			handler:     commandUsers,
+		},
+		{
+			name:        "reset",
+			usage:       "reset",
+			description: "Delete all users, feeds and posts",
+			handler:     commandReset,
+		},
+		{
+			name:        "agg",
+			usage:       "agg <time_between_reqs> [--prune]",
+			description: "Fetch feeds continuously, one feed per interval (e.g. 1m)",
+			minArgs:     1,
+			maxArgs:     1,
+			flags: func(fs *flag.FlagSet) {
+				fs.Bool("prune", false, "prune each feed after fetching it")
+			},
+			handler: commandAgg,
+		},
+		{
+			name:        "addfeed",
+			usage:       "addfeed <feed_name> <feed_url>",
+			description: "Add a feed and follow it",
+			minArgs:     2,
+			maxArgs:     2,
+			handler:     middlewareLoggedIn(commandAddFeed),
+		},
+		{
+			name:        "feeds",
+			usage:       "feeds",
+			description: "List all feeds",
+			listing:     true,
+			handler:     commandFeeds,
+		},
+		{
+			name:        "follow",
+			usage:       "follow <feed_url|feed_name>",
+			args:        []argKind{argFeed},
+			description: "Follow an existing feed",
+			minArgs:     1,
+			maxArgs:     1,
+			handler:     middlewareLoggedIn(commandFollow),
+		},
+		{
+			name:        "following",
+			usage:       "following",
+			description: "List feeds the current user follows",
+			listing:     true,
+			handler:     middlewareLoggedIn(commandFollowing),
+		},
+		{
+			name:        "filter",
+			usage:       "filter add|delete|list|test [args] [--limit n]",
+			description: "Manage rules that include, exclude or highlight posts in browse and search: add <action> <kind> <pattern>, delete <id>, list, test",
+			minArgs:     1,
+			maxArgs:     4,
+			args:        []argKind{argFilterAction},
+			flags: func(fs *flag.FlagSet) {
+				fs.Int("limit", 20, "number of recent posts filter test checks")
+			},
+			listing: true,
+			handler: middlewareLoggedIn(commandFilter),
+		},
+		{
+			name:        "webhook",
+			usage:       "webhook add|delete|list|log [args] [--secret s] [--limit n]",
+			description: "POST new posts to a URL as JSON: add <url> [feed], delete <id>, list, log [id]",
+			minArgs:     1,
+			maxArgs:     3,
+			args:        []argKind{argWebhookAction},
+			flags: func(fs *flag.FlagSet) {
+				fs.String("secret", "", "key for the X-Gator-Signature HMAC (default a random one, printed once)")
+				fs.Int("limit", 20, "number of deliveries webhook log shows")
+			},
+			listing: true,
+			handler: middlewareLoggedIn(commandWebhook),
+		},
+		{
+			name:        "digest",
+			usage:       "digest preview|send|schedule [args] [--days n] [--to address] [--html]",
+			description: "Email a digest of unread posts: preview, send, schedule [daily|weekly|off] [address]",
+			minArgs:     1,
+			maxArgs:     3,
+			args:        []argKind{argDigestAction},
+			flags: func(fs *flag.FlagSet) {
+				fs.Int("days", 0, "cover the last n days instead of the time since the last digest")
+				fs.String("to", "", "address digest send mails to instead of the scheduled one")
+				fs.Bool("html", false, "preview the HTML version")
+			},
+			handler: middlewareLoggedIn(commandDigest),
+		},
+		{
+			name:        "rename-feed",
+			usage:       "rename-feed <feed_url|feed_name> [name]",
+			description: "Set your own name for a followed feed, or reset it",
+			minArgs:     1,
+			maxArgs:     2,
+			args:        []argKind{argFeed},
+			handler:     middlewareLoggedIn(commandRenameFeed),
+		},
+		{
+			name:        "folder",
+			usage:       "folder create|rename|delete|move <args>",
+			description: "Organize followed feeds: create <name>, rename <name> <new_name>, delete <name>, move <feed> [name]",
+			minArgs:     2,
+			maxArgs:     3,
+			args:        []argKind{argFolderAction},
+			handler:     middlewareLoggedIn(commandFolder),
+		},
+		{
+			name:        "opml",
+			usage:       "opml import|export [file]",
+			description: "Import subscriptions from OPML or export them, with folders",
+			minArgs:     1,
+			maxArgs:     2,
+			args:        []argKind{argOPMLAction},
+			handler:     middlewareLoggedIn(commandOPML),
+		},
+		{
+			name:        "unfollow",
+			usage:       "unfollow <feed_url|feed_name>",
+			args:        []argKind{argFeed},
+			description: "Stop following a feed",
+			minArgs:     1,
+			maxArgs:     1,
+			handler:     middlewareLoggedIn(commandUnfollow),
+		},
+		{
+			name:        "browse",
+			usage:       "browse [limit] [--limit n] [--folder name] [--tag tag] [--saved name] [--full]",
+			description: "Show the newest posts from followed feeds",
+			maxArgs:     1,
+			flags: func(fs *flag.FlagSet) {
+				fs.Int("limit", 2, "number of posts to show")
+				fs.String("folder", "", "only show posts from feeds in this folder")
+				fs.String("tag", "", "only show posts with this tag")
+				fs.String("saved", "", "show the posts matching this saved search")
+				fs.Bool("full", false, "show the full article instead of the summary (default from full_content in the config)")
+			},
+			listing: true,
+			handler: middlewareLoggedIn(commandBrowse),
+		},
+		{
+			name:        "search",
+			usage:       "search <query> [--limit n] [--tag tag] [--folder name] [--days n] [--save name] [--full]",
+			description: "Find followed posts whose title or text contains the query",
+			minArgs:     1,
+			maxArgs:     1,
+			flags: func(fs *flag.FlagSet) {
+				fs.Int("limit", 10, "number of posts to show")
+				fs.String("tag", "", "only show posts with this tag")
+				fs.String("folder", "", "only show posts from feeds in this folder")
+				fs.Int("days", 0, "only show posts published in the last n days")
+				fs.String("save", "", "also save the search under this name for browse --saved")
+				fs.Bool("full", false, "show the full article instead of the summary (default from full_content in the config)")
+			},
+			listing: true,
+			handler: middlewareLoggedIn(commandSearch),
+		},
+		{
+			name:        "searches",
+			usage:       "searches [delete <name>]",
+			description: "List saved searches, or delete one",
+			maxArgs:     2,
+			listing:     true,
+			handler:     middlewareLoggedIn(commandSearches),
+		},
+		{
+			name:        "tags",
+			usage:       "tags [feed_url|feed_name] [--limit n]",
+			description: "List the most common tags on a feed or on all followed feeds",
+			maxArgs:     1,
+			args:        []argKind{argFeed},
+			flags: func(fs *flag.FlagSet) {
+				fs.Int("limit", 20, "number of tags to show")
+			},
+			listing: true,
+			handler: middlewareLoggedIn(commandTags),
+		},
+		{
+			name:        "open",
+			usage:       "open <post_id> [--exec template] [--keep-unread]",
+			description: "Open a post with the configured opener and mark it read",
+			minArgs:     1,
+			maxArgs:     1,
+			flags: func(fs *flag.FlagSet) {
+				fs.String("exec", "", "command template to run instead of the opener, e.g. 'notify-send {{.Title}} {{.URL}}'; the post is piped to it as JSON")
+				fs.Bool("keep-unread", false, "do not mark the post as read")
+			},
+			handler: middlewareLoggedIn(commandOpen),
+		},
+		{
+			name:        "download",
+			usage:       "download <post_id> [--dir path]",
+			description: "Download a post's enclosures, resuming partial downloads",
+			minArgs:     1,
+			maxArgs:     1,
+			flags: func(fs *flag.FlagSet) {
+				fs.String("dir", "", "directory to save to (default from download_dir in the config, or ~/Downloads)")
+			},
+			handler: middlewareLoggedIn(commandDownload),
+		},
+		{
+			name:        "read",
+			usage:       "read",
+			description: "Open the full-screen reader for followed feeds",
+			handler:     middlewareLoggedIn(commandRead),
+		},
+		{
+			name:        "prune",
+			usage:       "prune [feed_url|feed_name]",
+			description: "Delete posts outside the retention policy",
+			maxArgs:     1,
+			args:        []argKind{argFeed},
+			listing:     true,
+			handler:     commandPrune,
+		},
+		{
+			name:        "fulltext",
+			usage:       "fulltext <feed_url|feed_name> [on|off]",
+			description: "Show or set whether agg extracts full articles from a feed's pages",
+			minArgs:     1,
+			maxArgs:     2,
+			args:        []argKind{argFeed, argOnOff},
+			handler:     middlewareLoggedIn(commandFullText),
+		},
+		{
+			name:        "retention",
+			usage:       "retention <feed_url|feed_name> [--days n] [--max-posts n] [--reset]",
+			description: "Show or set how long a feed keeps its posts",
+			minArgs:     1,
+			maxArgs:     1,
+			args:        []argKind{argFeed},
+			flags: func(fs *flag.FlagSet) {
+				fs.Int("days", 0, "delete posts older than n days (0 keeps them)")
+				fs.Int("max-posts", 0, "keep only the newest n posts (0 for no limit)")
+				fs.Bool("reset", false, "use the configured defaults again")
+			},
+			handler: middlewareLoggedIn(commandRetention),
+		},
+	}
+	for _, spec := range specs {
+		if err := c.register(spec); err != nil {
+			log.Fatal(err)
+		}
 	}
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"time"
+)
+
+//go:embed sql/schema/*.sql sql/sqlite/schema/*.sql sql/sqlite/queries/*.sql
+var embeddedSQL embed.FS
+
+// embeddedDir returns one directory of the embedded sql tree, e.g.
+// "sql/schema" for the Postgres migrations.
+func embeddedDir(dir string) fs.FS {
+	sub, err := fs.Sub(embeddedSQL, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// checkSchema refuses to run commands against a database that is not at
+// the schema version this build was written for. It only queries the
+// database once per process.
+func (s *state) checkSchema() error {
+	if s.schemaChecked {
+		return nil
+	}
+	current, err := s.migrator.Current(context.Background())
+	if err != nil {
+		return fmt.Errorf("checking database schema version: %w", err)
+	}
+	latest := s.migrator.Latest()
+	switch {
+	case current < latest:
+		return fmt.Errorf("database schema is at version %d but gator needs version %d, run \"gator migrate up\"", current, latest)
+	case current > latest:
+		return fmt.Errorf("database schema version %d is newer than this gator build supports (%d), upgrade gator", current, latest)
+	}
+	s.schemaChecked = true
+	return nil
+}
+
+func commandMigrate(s *state, cmd command) error {
+	ctx := context.Background()
+	switch cmd.arguments[0] {
+	case "up":
+		applied, err := s.migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("Applied %s\n", migration)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database schema is up to date")
+		}
+	case "down":
+		migration, err := s.migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %s\n", migration)
+	case "status":
+		return migrateStatus(s)
+	default:
+		return fmt.Errorf("unknown migrate action %q (expected up, down or status)", cmd.arguments[0])
+	}
+	s.schemaChecked = false
+	return nil
+}
+
+func migrateStatus(s *state) error {
+	statuses, err := s.migrator.Status(context.Background())
+	if err != nil {
+		return err
+	}
+	type statusRecord struct {
+		Version   int64  `json:"version"`
+		Name      string `json:"name"`
+		Applied   bool   `json:"applied"`
+		AppliedAt string `json:"applied_at,omitempty"`
+	}
+	records := make([]statusRecord, 0, len(statuses))
+	rows := make([][]string, 0, len(statuses))
+	for _, status := range statuses {
+		record := statusRecord{Version: status.Migration.Version, Name: status.Migration.Name, Applied: status.Applied}
+		if status.Applied && !status.AppliedAt.IsZero() {
+			record.AppliedAt = s.localTime(status.AppliedAt).Format(time.RFC3339)
+		}
+		records = append(records, record)
+		rows = append(rows, []string{strconv.FormatInt(record.Version, 10), record.Name, strconv.FormatBool(record.Applied), record.AppliedAt})
+	}
+	return s.render(listing{
+		columns: []string{"version", "name", "applied", "applied_at"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			for idx, record := range records {
+				state := "pending"
+				if record.Applied {
+					state = "applied " + record.AppliedAt
+				}
+				fmt.Fprintf(w, "%s  %s\n", statuses[idx].Migration, state)
+			}
+		},
+	})
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+// postFields are the values available to opener and --exec templates,
+// e.g. "firefox --new-tab {{.URL}}" or "notify-send {{.Feed}} {{.Title}}".
+type postFields struct {
+	ID          int64
+	Feed        string
+	Title       string
+	URL         string
+	Author      string
+	Description string
+	Content     string
+	Text        string
+	PublishedAt time.Time
+}
+
+func commandOpen(s *state, cmd command, user database.User) error {
+	id, err := strconv.ParseInt(strings.TrimPrefix(cmd.arguments[0], "#"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid post id %q", cmd.arguments[0])
+	}
+	ctx := context.Background()
+	post, err := s.repo.GetPostByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no post with id %d", id)
+		}
+		return err
+	}
+	fields := postFields{
+		ID:          post.ID,
+		Feed:        post.FeedName,
+		Title:       post.Title,
+		URL:         post.Url,
+		Author:      post.Author,
+		Description: post.Description,
+		Content:     post.Content,
+		Text:        postBody(post.Description, post.Content, true),
+		PublishedAt: s.localTime(post.PublishedAt),
+	}
+
+	execTemplate := cmd.stringFlag("exec")
+	if execTemplate != "" {
+		record, err := json.Marshal(postRecord{
+			ID:          post.ID,
+			FeedName:    post.FeedName,
+			Title:       post.Title,
+			Url:         post.Url,
+			PublishedAt: s.localTime(post.PublishedAt).Format(time.RFC3339),
+			Description: post.Description,
+			Content:     post.Content,
+			Author:      post.Author,
+		})
+		if err != nil {
+			return err
+		}
+		if err := runTemplate(execTemplate, fields, append(record, '\n')); err != nil {
+			return err
+		}
+	} else if err := runTemplate(openerTemplate(s), fields, nil); err != nil {
+		return err
+	}
+
+	if cmd.boolFlag("keep-unread") {
+		return nil
+	}
+	return s.repo.MarkPostRead(ctx, database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+}
+
+// openerTemplate picks the configured opener, then $BROWSER, then the
+// platform's default URL handler. An opener without any {{...}} action gets
+// the post URL as its last argument.
+func openerTemplate(s *state) string {
+	opener := s.cfg.Opener
+	if opener == "" {
+		opener = os.Getenv("BROWSER")
+	}
+	if opener == "" {
+		switch runtime.GOOS {
+		case "darwin":
+			opener = "open"
+		case "windows":
+			opener = "rundll32 url.dll,FileProtocolHandler"
+		default:
+			opener = "xdg-open"
+		}
+	}
+	if !strings.Contains(opener, "{{") {
+		opener += " {{.URL}}"
+	}
+	return opener
+}
+
+// splitCommandTemplate splits a command template into words like
+// splitCommandLine, except that each {{...}} action stays whole, so actions
+// may contain spaces and quotes of their own.
+func splitCommandTemplate(commandTemplate string) ([]string, error) {
+	var actions []string
+	var line strings.Builder
+	rest := commandTemplate
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed action in command template %q", commandTemplate)
+		}
+		end += start + len("}}")
+		line.WriteString(rest[:start])
+		fmt.Fprintf(&line, "\x00%d\x00", len(actions))
+		actions = append(actions, rest[start:end])
+		rest = rest[end:]
+	}
+	line.WriteString(rest)
+
+	words, err := splitCommandLine(line.String())
+	if err != nil {
+		return nil, err
+	}
+	for idx := range words {
+		for n, action := range actions {
+			words[idx] = strings.ReplaceAll(words[idx], fmt.Sprintf("\x00%d\x00", n), action)
+		}
+	}
+	return words, nil
+}
+
+// runTemplate splits a command template into words before expanding each
+// one, so post fields never get re-split or interpreted by a shell. stdin,
+// if non-nil, is piped to the command.
+func runTemplate(commandTemplate string, fields postFields, stdin []byte) error {
+	words, err := splitCommandTemplate(commandTemplate)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("empty command template")
+	}
+	args := make([]string, 0, len(words))
+	for _, word := range words {
+		tmpl, err := template.New("command").Option("missingkey=error").Parse(word)
+		if err != nil {
+			return fmt.Errorf("invalid command template %q: %w", commandTemplate, err)
+		}
+		var expanded bytes.Buffer
+		if err := tmpl.Execute(&expanded, fields); err != nil {
+			return fmt.Errorf("invalid command template %q: %w", commandTemplate, err)
+		}
+		args = append(args, expanded.String())
+	}
+
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if stdin != nil {
+		c.Stdin = bytes.NewReader(stdin)
+	} else {
+		c.Stdin = os.Stdin
+	}
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", args[0], err)
+	}
+	return nil
+}
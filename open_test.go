@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		want     []string
+	}{
+		{"echo {{.URL}}", []string{"echo", "{{.URL}}"}},
+		{"echo {{ .URL }}", []string{"echo", "{{ .URL }}"}},
+		{`echo {{printf "%s" .Title}}`, []string{"echo", `{{printf "%s" .Title}}`}},
+		{`notify-send "New: {{ .Title }}" {{.URL}}`, []string{"notify-send", "New: {{ .Title }}", "{{.URL}}"}},
+		{"mpv --title={{ .Title }} {{.URL}}", []string{"mpv", "--title={{ .Title }}", "{{.URL}}"}},
+	}
+	for _, test := range tests {
+		got, err := splitCommandTemplate(test.template)
+		if err != nil {
+			t.Errorf("splitCommandTemplate(%q): %v", test.template, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitCommandTemplate(%q) = %q, want %q", test.template, got, test.want)
+		}
+	}
+
+	if _, err := splitCommandTemplate("echo {{ .URL"); err == nil {
+		t.Error("splitCommandTemplate accepted an unclosed action")
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+// opmlDocument is the subset of OPML 2.0 that feed readers exchange: feeds
+// are outlines with an xmlUrl, and folders are outlines that contain them.
+type opmlDocument struct {
+	XMLName     xml.Name      `xml:"opml"`
+	Version     string        `xml:"version,attr"`
+	Title       string        `xml:"head>title"`
+	DateCreated string        `xml:"head>dateCreated,omitempty"`
+	Outlines    []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Type     string        `xml:"type,attr,omitempty"`
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+func (o opmlOutline) name() string {
+	if text := strings.TrimSpace(o.Text); text != "" {
+		return text
+	}
+	return strings.TrimSpace(o.Title)
+}
+
+// commandOPML imports subscriptions from an OPML file or exports the
+// current user's to one, with folders as nested outlines.
+func commandOPML(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	switch cmd.arguments[0] {
+	case "export":
+		if len(cmd.arguments) == 1 {
+			return exportOPML(ctx, s, user, os.Stdout)
+		}
+		file, err := os.Create(cmd.arguments[1])
+		if err != nil {
+			return err
+		}
+		if err := exportOPML(ctx, s, user, file); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	case "import":
+		if len(cmd.arguments) != 2 {
+			return errors.New("usage: opml import <file>")
+		}
+		data, err := os.ReadFile(cmd.arguments[1])
+		if err != nil {
+			return err
+		}
+		return importOPML(ctx, s, user, data)
+	default:
+		return fmt.Errorf("unknown opml action %q (expected import or export)", cmd.arguments[0])
+	}
+}
+
+func exportOPML(ctx context.Context, s *state, user database.User, w io.Writer) error {
+	folders, err := s.db.GetFoldersForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	follows, err := s.repo.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	doc := opmlDocument{
+		Version:     "2.0",
+		Title:       fmt.Sprintf("gator subscriptions for %s", user.Name),
+		DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+	}
+	folderOutlines := make(map[int64]int, len(folders))
+	for _, folder := range folders {
+		folderOutlines[folder.ID] = len(doc.Outlines)
+		doc.Outlines = append(doc.Outlines, opmlOutline{Text: folder.Name, Title: folder.Name})
+	}
+	for _, follow := range follows {
+		outline := opmlOutline{Type: "rss", Text: follow.FeedName, Title: follow.FeedName, XMLURL: follow.FeedUrl}
+		if idx, ok := folderOutlines[follow.FolderID.Int64]; ok && follow.FolderID.Valid {
+			doc.Outlines[idx].Outlines = append(doc.Outlines[idx].Outlines, outline)
+		} else {
+			doc.Outlines = append(doc.Outlines, outline)
+		}
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// importOPML follows every feed in the document, adding feeds gator does
+// not know yet, and files them under their folder outlines. Nested folders
+// are flattened into one folder named by their path, as in "Tech/Go", and
+// only created where they hold feeds or are empty. The import is all or
+// nothing.
+func importOPML(ctx context.Context, s *state, user database.User, data []byte) error {
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("reading OPML: %w", err)
+	}
+	var imported, added int
+	err := s.inTx(ctx, func(tx *state) error {
+		follows, err := tx.repo.GetFeedFollowsForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		followed := make(map[int64]bool, len(follows))
+		for _, follow := range follows {
+			followed[follow.FeedID] = true
+		}
+		var walk func(outlines []opmlOutline, path []string) error
+		walk = func(outlines []opmlOutline, path []string) error {
+			for _, outline := range outlines {
+				if outline.XMLURL == "" {
+					name := outline.name()
+					if name == "" {
+						if err := walk(outline.Outlines, path); err != nil {
+							return err
+						}
+						continue
+					}
+					folderPath := append(append([]string(nil), path...), name)
+					if len(outline.Outlines) == 0 {
+						if _, err := ensureFolder(ctx, tx, user, strings.Join(folderPath, "/")); err != nil {
+							return err
+						}
+					}
+					if err := walk(outline.Outlines, folderPath); err != nil {
+						return err
+					}
+					continue
+				}
+				isNew, err := importFeed(ctx, tx, user, outline, strings.Join(path, "/"), followed)
+				if err != nil {
+					return err
+				}
+				imported++
+				if isNew {
+					added++
+				}
+			}
+			return nil
+		}
+		return walk(doc.Outlines, nil)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %s (%d new to gator)\n", pluralize(imported, "feed"), added)
+	return nil
+}
+
+// importFeed follows the outline's feed, creating it first if needed, and
+// files it under folderName when that is not empty. An outline title that
+// differs from the feed's name becomes the user's display name for it, as
+// exports write display names there. followed holds the IDs of the feeds
+// the user follows and is updated as feeds are followed. It reports
+// whether the feed was new.
+func importFeed(ctx context.Context, s *state, user database.User, outline opmlOutline, folderName string, followed map[int64]bool) (bool, error) {
+	now := time.Now().UTC()
+	feedURL := strings.TrimSpace(outline.XMLURL)
+	isNew := false
+	feed, err := s.repo.GetFeedFromURL(ctx, feedURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		name := outline.name()
+		if name == "" {
+			name = feedURL
+		}
+		feed, err = s.repo.CreateFeed(ctx, database.CreateFeedParams{CreatedAt: now, UpdatedAt: now, Name: name, Url: feedURL, UserID: user.ID})
+		isNew = true
+	}
+	if err != nil {
+		return false, err
+	}
+	// A failed insert would abort the transaction on Postgres, so feeds
+	// already followed are not followed again.
+	if !followed[feed.ID] {
+		if _, err := s.repo.CreateFeedFollow(ctx, database.CreateFeedFollowParams{CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID}); err != nil {
+			return false, err
+		}
+		followed[feed.ID] = true
+	}
+	displayName := strings.TrimSpace(outline.Title)
+	if displayName == "" {
+		displayName = outline.name()
+	}
+	if displayName != "" && displayName != feed.Name {
+		if _, err := s.repo.SetFeedFollowDisplayName(ctx, database.SetFeedFollowDisplayNameParams{
+			UserID:      user.ID,
+			FeedID:      feed.ID,
+			DisplayName: sql.NullString{String: displayName, Valid: true},
+			UpdatedAt:   now,
+		}); err != nil {
+			return false, err
+		}
+	}
+	if folderName == "" {
+		return isNew, nil
+	}
+	folder, err := ensureFolder(ctx, s, user, folderName)
+	if err != nil {
+		return false, err
+	}
+	_, err = s.db.SetFeedFollowFolder(ctx, database.SetFeedFollowFolderParams{
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+		FolderID:  sql.NullInt64{Int64: folder.ID, Valid: true},
+		UpdatedAt: now,
+	})
+	return isNew, err
+}
+
+func ensureFolder(ctx context.Context, s *state, user database.User, name string) (database.Folder, error) {
+	folder, err := s.db.GetFolderByName(ctx, database.GetFolderByNameParams{UserID: user.ID, Name: name})
+	if !errors.Is(err, sql.ErrNoRows) {
+		return folder, err
+	}
+	now := time.Now().UTC()
+	return s.db.CreateFolder(ctx, database.CreateFolderParams{CreatedAt: now, UpdatedAt: now, UserID: user.ID, Name: name})
+}
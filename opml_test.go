@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+func TestOPMLRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	newUser := func(name string) database.User {
+		now := time.Now().UTC()
+		user, err := s.repo.CreateUser(ctx, database.CreateUserParams{CreatedAt: now, UpdatedAt: now, Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+	ann, bob := newUser("ann"), newUser("bob")
+
+	input := `<?xml version="1.0"?>
+<opml version="2.0">
+  <head><title>ann's feeds</title></head>
+  <body>
+    <outline text="Tech">
+      <outline text="Go">
+        <outline type="rss" text="Go Blog" xmlUrl="https://go.dev/blog/feed.atom"/>
+      </outline>
+      <outline type="rss" text="LWN" title="Linux news" xmlUrl="https://lwn.net/headlines/rss"/>
+    </outline>
+    <outline text="Empty"/>
+    <outline type="rss" text="xkcd" xmlUrl="https://xkcd.com/rss.xml"/>
+  </body>
+</opml>`
+	if err := importOPML(ctx, s, ann, []byte(input)); err != nil {
+		t.Fatal(err)
+	}
+	// Importing again follows nothing new and must not trip over the
+	// existing follows.
+	if err := importOPML(ctx, s, ann, []byte(input)); err != nil {
+		t.Fatalf("importing twice: %v", err)
+	}
+	want := []string{
+		"Go Blog <https://go.dev/blog/feed.atom> in Tech/Go",
+		"Linux news <https://lwn.net/headlines/rss> in Tech",
+		"xkcd <https://xkcd.com/rss.xml>",
+	}
+	wantFolders := []string{"Empty", "Tech", "Tech/Go"}
+	if got := opmlTestFollows(t, s, ann); !slices.Equal(got, want) {
+		t.Errorf("ann follows %q, want %q", got, want)
+	}
+	if got := opmlTestFolders(t, s, ann); !slices.Equal(got, wantFolders) {
+		t.Errorf("ann has folders %q, want %q", got, wantFolders)
+	}
+
+	var exported bytes.Buffer
+	if err := exportOPML(ctx, s, ann, &exported); err != nil {
+		t.Fatal(err)
+	}
+	if err := importOPML(ctx, s, bob, exported.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if got := opmlTestFollows(t, s, bob); !slices.Equal(got, want) {
+		t.Errorf("bob follows %q after importing ann's export, want %q", got, want)
+	}
+	if got := opmlTestFolders(t, s, bob); !slices.Equal(got, wantFolders) {
+		t.Errorf("bob has folders %q after importing ann's export, want %q", got, wantFolders)
+	}
+}
+
+func opmlTestFollows(t *testing.T, s *state, user database.User) []string {
+	t.Helper()
+	follows, err := s.repo.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, follow := range follows {
+		name := follow.FeedName
+		if follow.DisplayName.Valid {
+			name = follow.DisplayName.String
+		}
+		line := fmt.Sprintf("%s <%s>", name, follow.FeedUrl)
+		if follow.FolderName.Valid {
+			line += " in " + follow.FolderName.String
+		}
+		got = append(got, line)
+	}
+	slices.Sort(got)
+	return got
+}
+
+func opmlTestFolders(t *testing.T, s *state, user database.User) []string {
+	t.Helper()
+	folders, err := s.db.GetFoldersForUser(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, folder := range folders {
+		got = append(got, folder.Name)
+	}
+	slices.Sort(got)
+	return got
+}
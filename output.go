@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+type outputFormat string
+
+const (
+	outputPlain outputFormat = "plain"
+	outputJSON  outputFormat = "json"
+	outputCSV   outputFormat = "csv"
+	outputTable outputFormat = "table"
+)
+
+func parseOutputFormat(value string) (outputFormat, error) {
+	switch format := outputFormat(strings.ToLower(value)); format {
+	case outputPlain, outputJSON, outputCSV, outputTable:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (expected json, csv, table or plain)", value)
+}
+
+// outputFlag is the per-command --output flag of listing commands.
+type outputFlag struct {
+	format outputFormat
+	set    bool
+}
+
+func (f *outputFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(f.format)
+}
+
+func (f *outputFlag) Set(value string) error {
+	format, err := parseOutputFormat(value)
+	if err != nil {
+		return err
+	}
+	f.format, f.set = format, true
+	return nil
+}
+
+func (f *outputFlag) Get() any {
+	return f.format
+}
+
+// listing is the result of a listing command. records is encoded as-is for
+// json, columns and rows feed csv and table, and plain keeps each command's
+// own human-oriented text.
+type listing struct {
+	columns []string
+	rows    [][]string
+	records any
+	plain   func(w io.Writer)
+}
+
+func (s *state) render(l listing) error {
+	return renderListing(os.Stdout, s.output, l)
+}
+
+func renderListing(w io.Writer, format outputFormat, l listing) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(l.records)
+	case outputCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(l.columns); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(l.rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	case outputTable:
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.ToUpper(strings.Join(l.columns, "\t")))
+		for _, row := range l.rows {
+			cells := make([]string, len(row))
+			for idx, cell := range row {
+				cells[idx] = strings.Join(strings.Fields(cell), " ")
+			}
+			fmt.Fprintln(writer, strings.Join(cells, "\t"))
+		}
+		return writer.Flush()
+	default:
+		l.plain(w)
+		return nil
+	}
+}
+
+// parseGlobalOptions consumes options that appear before the command name.
+func parseGlobalOptions(args []string) (outputFormat, []string, error) {
+	format := outputPlain
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		option, value, hasValue := strings.Cut(args[0], "=")
+		switch option {
+		case "-o", "--output", "-output":
+			if !hasValue {
+				if len(args) < 2 {
+					return "", nil, fmt.Errorf("%s requires a value", option)
+				}
+				value = args[1]
+				args = args[1:]
+			}
+			parsed, err := parseOutputFormat(value)
+			if err != nil {
+				return "", nil, err
+			}
+			format = parsed
+		case "-h", "--help", "-help":
+			return format, append([]string{"help"}, args[1:]...), nil
+		default:
+			return "", nil, fmt.Errorf("unknown option %s", option)
+		}
+		args = args[1:]
+	}
+	return format, args, nil
+}
+
+type userRecord struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+}
+
+type feedRecord struct {
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	UserName string `json:"user_name"`
+}
+
+type followRecord struct {
+	FeedName    string `json:"feed_name"`
+	FeedUrl     string `json:"feed_url"`
+	Folder      string `json:"folder,omitempty"`
+	FollowedAt  string `json:"followed_at"`
+	SavedSearch string `json:"saved_search,omitempty"`
+}
+
+type postRecord struct {
+	ID          int64             `json:"id"`
+	FeedName    string            `json:"feed_name"`
+	Title       string            `json:"title"`
+	Url         string            `json:"url"`
+	PublishedAt string            `json:"published_at"`
+	Description string            `json:"description"`
+	Content     string            `json:"content,omitempty"`
+	Author      string            `json:"author,omitempty"`
+	Highlighted bool              `json:"highlighted,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Enclosures  []enclosureRecord `json:"enclosures,omitempty"`
+}
+
+type enclosureRecord struct {
+	Url             string `json:"url"`
+	MimeType        string `json:"mime_type,omitempty"`
+	Length          int64  `json:"length,omitempty"`
+	DurationSeconds int32  `json:"duration_seconds,omitempty"`
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+const defaultKeepUnreadDays = 30
+
+// retentionPolicy limits how many posts a feed keeps. Zero means no limit.
+type retentionPolicy struct {
+	days     int
+	maxPosts int
+}
+
+// feedRetention returns the feed's own retention settings where it has
+// them and the configured defaults otherwise.
+func feedRetention(s *state, feed database.Feed) retentionPolicy {
+	policy := retentionPolicy{days: s.cfg.RetentionDays, maxPosts: s.cfg.RetentionMaxPosts}
+	if feed.RetentionDays.Valid {
+		policy.days = int(feed.RetentionDays.Int32)
+	}
+	if feed.RetentionMaxPosts.Valid {
+		policy.maxPosts = int(feed.RetentionMaxPosts.Int32)
+	}
+	return policy
+}
+
+func (p retentionPolicy) String() string {
+	if p.days == 0 && p.maxPosts == 0 {
+		return "keep forever"
+	}
+	var limits []string
+	if p.days > 0 {
+		limits = append(limits, pluralize(p.days, "day"))
+	}
+	if p.maxPosts > 0 {
+		limits = append(limits, "newest "+pluralize(p.maxPosts, "post"))
+	}
+	if len(limits) == 2 {
+		return limits[0] + ", " + limits[1]
+	}
+	return limits[0]
+}
+
+// pruneFeed deletes the feed's posts that fall outside its retention
+// policy. Starred posts and unread posts newer than keep_unread_days are
+// kept regardless; keep_unread_days of zero or less keeps every unread post.
+func pruneFeed(ctx context.Context, s *state, feed database.Feed, now time.Time) (int64, error) {
+	policy := feedRetention(s, feed)
+	if policy.days == 0 && policy.maxPosts == 0 {
+		return 0, nil
+	}
+	keepUnreadDays := defaultKeepUnreadDays
+	if s.cfg.KeepUnreadDays != nil {
+		keepUnreadDays = *s.cfg.KeepUnreadDays
+	}
+	// The zero time keeps unread posts of any age.
+	var unreadSince time.Time
+	if keepUnreadDays > 0 {
+		unreadSince = now.AddDate(0, 0, -keepUnreadDays)
+	}
+	var publishedBefore time.Time
+	if policy.days > 0 {
+		publishedBefore = now.AddDate(0, 0, -policy.days)
+	}
+	return s.db.PrunePosts(ctx, database.PrunePostsParams{
+		FeedID:          feed.ID,
+		PublishedBefore: publishedBefore,
+		MaxPosts:        int64(policy.maxPosts),
+		UnreadSince:     unreadSince,
+	})
+}
+
+func commandPrune(s *state, cmd command) error {
+	ctx := context.Background()
+	var feeds []database.Feed
+	if len(cmd.arguments) == 1 {
+		feed, err := resolveFeed(ctx, s, cmd.arguments[0])
+		if err != nil {
+			return err
+		}
+		feeds = append(feeds, feed)
+	} else {
+		allFeeds, err := s.repo.GetAllFeeds(ctx)
+		if err != nil {
+			return err
+		}
+		feeds = allFeeds
+	}
+
+	type pruneRecord struct {
+		FeedName  string `json:"feed_name"`
+		FeedUrl   string `json:"feed_url"`
+		Retention string `json:"retention"`
+		Deleted   int64  `json:"deleted"`
+	}
+	now := time.Now().UTC()
+	records := make([]pruneRecord, 0, len(feeds))
+	rows := make([][]string, 0, len(feeds))
+	var total int64
+	for _, feed := range feeds {
+		deleted, err := pruneFeed(ctx, s, feed, now)
+		if err != nil {
+			return fmt.Errorf("pruning %s: %w", feed.Name, err)
+		}
+		total += deleted
+		record := pruneRecord{FeedName: feed.Name, FeedUrl: feed.Url, Retention: feedRetention(s, feed).String(), Deleted: deleted}
+		records = append(records, record)
+		rows = append(rows, []string{record.FeedName, record.FeedUrl, record.Retention, strconv.FormatInt(deleted, 10)})
+	}
+
+	return s.render(listing{
+		columns: []string{"feed_name", "feed_url", "retention", "deleted"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			for _, record := range records {
+				if record.Deleted > 0 {
+					fmt.Fprintf(w, "Pruned %s from %s\n", pluralize(int(record.Deleted), "post"), record.FeedName)
+				}
+			}
+			fmt.Fprintf(w, "Pruned %s in total\n", pluralize(int(total), "post"))
+		},
+	})
+}
+
+// commandRetention shows or changes a feed's retention settings. Only the
+// user who added the feed may change them.
+func commandRetention(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	feed, err := resolveUserFeed(ctx, s, user, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	changing := cmd.flagPassed("days") || cmd.flagPassed("max-posts") || cmd.boolFlag("reset")
+	if changing {
+		if feed.UserID != user.ID {
+			return fmt.Errorf("only the user who added %s can change its retention", feed.Name)
+		}
+		params := database.SetFeedRetentionParams{
+			ID:                feed.ID,
+			RetentionDays:     feed.RetentionDays,
+			RetentionMaxPosts: feed.RetentionMaxPosts,
+			UpdatedAt:         time.Now().UTC(),
+		}
+		if cmd.boolFlag("reset") {
+			params.RetentionDays = sql.NullInt32{}
+			params.RetentionMaxPosts = sql.NullInt32{}
+		}
+		if cmd.flagPassed("days") {
+			days := cmd.intFlag("days")
+			if days < 0 {
+				return fmt.Errorf("--days must not be negative")
+			}
+			params.RetentionDays = sql.NullInt32{Int32: int32(days), Valid: true}
+		}
+		if cmd.flagPassed("max-posts") {
+			maxPosts := cmd.intFlag("max-posts")
+			if maxPosts < 0 {
+				return fmt.Errorf("--max-posts must not be negative")
+			}
+			params.RetentionMaxPosts = sql.NullInt32{Int32: int32(maxPosts), Valid: true}
+		}
+		if err := s.db.SetFeedRetention(ctx, params); err != nil {
+			return err
+		}
+		feed.RetentionDays, feed.RetentionMaxPosts = params.RetentionDays, params.RetentionMaxPosts
+	}
+
+	source := "feed setting"
+	if !feed.RetentionDays.Valid && !feed.RetentionMaxPosts.Valid {
+		source = "default"
+	}
+	fmt.Printf("%s: %s (%s)\n", feed.Name, feedRetention(s, feed), source)
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+func TestPruneFeedKeepsPostsStoredRecently(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	s.cfg.RetentionDays = 7
+	_, feed := createTestFeed(t, s, "ann")
+
+	now := time.Now().UTC()
+	posts := []struct {
+		title                string
+		createdAt, published time.Time
+		kept                 bool
+	}{
+		{"undated", now, time.Time{}, true},
+		{"old date, fetched today", now, now.AddDate(-3, 0, 0), true},
+		{"fetched long ago", now.AddDate(0, -2, 0), now.AddDate(0, -2, 0), false},
+	}
+	for _, post := range posts {
+		if _, err := s.repo.CreatePost(ctx, database.CreatePostParams{
+			CreatedAt:   post.createdAt,
+			UpdatedAt:   post.createdAt,
+			PublishedAt: post.published,
+			Title:       post.title,
+			Url:         feed.Url + "#" + post.title,
+			FeedID:      feed.ID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := pruneFeed(ctx, s, feed, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Errorf("pruned %d posts, want 1", deleted)
+	}
+	remaining, err := s.repo.GetPostsForUserFeed(ctx, database.GetPostsForUserFeedParams{UserID: feed.UserID, FeedID: feed.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := make(map[string]bool)
+	for _, post := range remaining {
+		kept[post.Title] = true
+	}
+	for _, post := range posts {
+		if kept[post.title] != post.kept {
+			t.Errorf("post %q kept = %v, want %v", post.title, kept[post.title], post.kept)
+		}
+	}
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+const (
+	readerPostLimit     = 200
+	readerFeedPaneWidth = 32
+	readerHelp          = "j/k move  tab/enter switch pane  h back  s star  f full text  space page  q quit"
+)
+
+type readerPane int
+
+const (
+	paneFeeds readerPane = iota
+	panePosts
+	panePreview
+)
+
+type readerFeed struct {
+	id      int64
+	name    string
+	unread  int64
+	starred bool
+}
+
+type readerPost struct {
+	id          int64
+	feedID      int64
+	feedName    string
+	title       string
+	url         string
+	description string
+	content     string
+	author      string
+	publishedAt time.Time
+	read        bool
+	starred     bool
+	highlighted bool
+}
+
+// reader is the full-screen terminal UI behind the read command: followed
+// feeds on the left, the selected feed's posts top right and a preview of
+// the selected post below it.
+type reader struct {
+	ctx           context.Context
+	s             *state
+	user          database.User
+	rules         []filterRule
+	out           *bufio.Writer
+	feeds         []readerFeed
+	posts         []readerPost
+	feedCursor    int
+	feedOffset    int
+	postCursor    int
+	postOffset    int
+	previewOffset int
+	focus         readerPane
+	status        string
+	fullContent   bool
+	width         int
+	height        int
+}
+
+func commandRead(s *state, cmd command, user database.User) error {
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("read needs an interactive terminal, use browse instead")
+	}
+	r := &reader{
+		ctx:         context.Background(),
+		s:           s,
+		user:        user,
+		out:         bufio.NewWriter(os.Stdout),
+		fullContent: s.cfg.FullContent,
+	}
+	rules, err := loadFilterRules(r.ctx, s, user.ID)
+	if err != nil {
+		return err
+	}
+	r.rules = rules
+	if err := r.loadFeeds(); err != nil {
+		return err
+	}
+	r.loadPosts()
+
+	oldState, err := term.MakeRaw(stdin)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(stdin, oldState)
+	fmt.Fprint(r.out, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(r.out, "\x1b[?25h\x1b[?1049l")
+		r.out.Flush()
+	}()
+
+	buf := make([]byte, 32)
+	for {
+		r.draw()
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		if !r.handleKey(string(buf[:n])) {
+			return nil
+		}
+	}
+}
+
+func (r *reader) loadFeeds() error {
+	follows, err := r.s.repo.GetFeedFollowsForUser(r.ctx, r.user.ID)
+	if err != nil {
+		return err
+	}
+	counts, err := r.s.db.GetUnreadCountsForUser(r.ctx, r.user.ID)
+	if err != nil {
+		return err
+	}
+	unread := make(map[int64]int64, len(counts))
+	for _, count := range counts {
+		unread[count.FeedID] = count.Unread
+	}
+	r.feeds = []readerFeed{{name: "★ Starred", starred: true}}
+	for _, follow := range follows {
+		r.feeds = append(r.feeds, readerFeed{id: follow.FeedID, name: follow.FeedName, unread: unread[follow.FeedID]})
+	}
+	return nil
+}
+
+func (r *reader) loadPosts() {
+	r.posts = nil
+	r.postCursor, r.postOffset, r.previewOffset = 0, 0, 0
+	feed := r.feeds[r.feedCursor]
+	if feed.starred {
+		posts, err := r.s.db.GetStarredPostsForUser(r.ctx, database.GetStarredPostsForUserParams{UserID: r.user.ID, Limit: readerPostLimit})
+		if err != nil {
+			r.status = err.Error()
+			return
+		}
+		for _, post := range posts {
+			r.addPost(readerPost{
+				id:          post.ID,
+				feedID:      post.FeedID,
+				feedName:    post.FeedName,
+				title:       post.Title,
+				url:         post.Url,
+				description: post.Description,
+				content:     post.Content,
+				author:      post.Author,
+				publishedAt: r.s.localTime(post.PublishedAt),
+				read:        post.ReadAt.Valid,
+				starred:     post.Starred,
+			}, false)
+		}
+		return
+	}
+	posts, err := r.s.repo.GetPostsForUserFeed(r.ctx, database.GetPostsForUserFeedParams{UserID: r.user.ID, FeedID: feed.id, Limit: readerPostLimit})
+	if err != nil {
+		r.status = err.Error()
+		return
+	}
+	for _, post := range posts {
+		r.addPost(readerPost{
+			id:          post.ID,
+			feedID:      post.FeedID,
+			feedName:    feed.name,
+			title:       post.Title,
+			url:         post.Url,
+			description: post.Description,
+			content:     post.Content,
+			author:      post.Author,
+			publishedAt: r.s.localTime(post.PublishedAt),
+			read:        post.ReadAt.Valid,
+			starred:     post.Starred,
+		}, true)
+	}
+}
+
+// addPost applies the user's filter rules to post and lists it unless they
+// hide it. Starred posts were picked by hand, so they are only ever
+// highlighted, never hidden.
+func (r *reader) addPost(post readerPost, hide bool) {
+	verdict := evaluateFilters(r.rules, &filterPost{
+		feedID:      post.feedID,
+		title:       post.title,
+		description: post.description,
+		content:     post.content,
+		author:      post.author,
+	})
+	if hide && verdict.hidden {
+		return
+	}
+	post.highlighted = verdict.highlighted
+	r.posts = append(r.posts, post)
+}
+
+// handleKey applies one keypress and reports whether the reader should
+// keep running.
+func (r *reader) handleKey(key string) bool {
+	r.status = ""
+	switch key {
+	case "q", "\x03", "\x04":
+		return false
+	case "j", "\x1b[B":
+		r.move(1)
+	case "k", "\x1b[A":
+		r.move(-1)
+	case " ", "\x1b[6~":
+		r.page(1)
+	case "b", "\x1b[5~":
+		r.page(-1)
+	case "\t":
+		r.setFocus((r.focus + 1) % 3)
+	case "\r", "l", "\x1b[C":
+		if r.focus < panePreview {
+			r.setFocus(r.focus + 1)
+		}
+	case "h", "\x1b[D", "\x1b":
+		if r.focus > paneFeeds {
+			r.setFocus(r.focus - 1)
+		}
+	case "s":
+		r.toggleStar()
+	case "f":
+		r.fullContent = !r.fullContent
+		r.previewOffset = 0
+	}
+	return true
+}
+
+// setFocus moves focus to pane. A post counts as read once it is shown in
+// the preview pane, not while the cursor passes over it in the list.
+func (r *reader) setFocus(pane readerPane) {
+	r.focus = pane
+	if pane == panePreview {
+		r.markCurrentRead()
+	}
+}
+
+func (r *reader) move(delta int) {
+	switch r.focus {
+	case paneFeeds:
+		cursor := clamp(r.feedCursor+delta, 0, len(r.feeds)-1)
+		if cursor != r.feedCursor {
+			r.feedCursor = cursor
+			r.loadPosts()
+		}
+	case panePosts:
+		cursor := clamp(r.postCursor+delta, 0, len(r.posts)-1)
+		if cursor != r.postCursor {
+			r.postCursor = cursor
+			r.previewOffset = 0
+		}
+	case panePreview:
+		r.previewOffset = max(r.previewOffset+delta, 0)
+	}
+}
+
+func (r *reader) page(direction int) {
+	_, previewHeight := r.paneHeights()
+	if r.focus == paneFeeds {
+		r.setFocus(panePreview)
+	}
+	r.previewOffset = max(r.previewOffset+direction*max(previewHeight-1, 1), 0)
+}
+
+func (r *reader) currentPost() *readerPost {
+	if len(r.posts) == 0 {
+		return nil
+	}
+	return &r.posts[r.postCursor]
+}
+
+func (r *reader) markCurrentRead() {
+	post := r.currentPost()
+	if post == nil || post.read {
+		return
+	}
+	if err := r.s.repo.MarkPostRead(r.ctx, database.MarkPostReadParams{
+		UserID: r.user.ID,
+		PostID: post.id,
+		ReadAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	}); err != nil {
+		r.status = err.Error()
+		return
+	}
+	post.read = true
+	for idx := range r.feeds {
+		if r.feeds[idx].id == post.feedID && !r.feeds[idx].starred && r.feeds[idx].unread > 0 {
+			r.feeds[idx].unread--
+		}
+	}
+}
+
+func (r *reader) toggleStar() {
+	post := r.currentPost()
+	if post == nil || r.focus == paneFeeds {
+		return
+	}
+	if err := r.s.repo.SetPostStarred(r.ctx, database.SetPostStarredParams{
+		UserID:  r.user.ID,
+		PostID:  post.id,
+		Starred: !post.starred,
+	}); err != nil {
+		r.status = err.Error()
+		return
+	}
+	post.starred = !post.starred
+	if post.starred {
+		r.status = "Starred"
+	} else {
+		r.status = "Unstarred"
+	}
+}
+
+func (r *reader) paneHeights() (int, int) {
+	body := max(r.height-2, 2)
+	postsHeight := max(body/2-1, 1)
+	return postsHeight, max(body-postsHeight-1, 1)
+}
+
+func (r *reader) draw() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = defaultTerminalWidth, 24
+	}
+	r.width, r.height = width, height
+	feedWidth := min(readerFeedPaneWidth, max(width/3, 10))
+	rightWidth := max(width-feedWidth-1, 10)
+	postsHeight, previewHeight := r.paneHeights()
+	body := postsHeight + 1 + previewHeight
+
+	r.feedOffset = scrollOffset(r.feedCursor, r.feedOffset, body)
+	r.postOffset = scrollOffset(r.postCursor, r.postOffset, postsHeight)
+
+	preview := r.previewLines(rightWidth)
+	r.previewOffset = min(r.previewOffset, max(len(preview)-previewHeight, 0))
+
+	header := fmt.Sprintf(" gator · %s", r.user.Name)
+	if r.status != "" {
+		header += " · " + r.status
+	}
+	fmt.Fprint(r.out, "\x1b[H")
+	fmt.Fprintf(r.out, "\x1b[7m%s\x1b[0m\x1b[K", fitText(header, width))
+
+	for row := 0; row < body; row++ {
+		fmt.Fprintf(r.out, "\x1b[%d;1H", row+2)
+		r.out.WriteString(r.feedCell(r.feedOffset+row, feedWidth))
+		r.out.WriteString("│")
+		switch {
+		case row < postsHeight:
+			r.out.WriteString(r.postCell(r.postOffset+row, rightWidth))
+		case row == postsHeight:
+			r.out.WriteString(strings.Repeat("─", rightWidth))
+		default:
+			idx := r.previewOffset + row - postsHeight - 1
+			line := ""
+			if idx < len(preview) {
+				line = preview[idx]
+			}
+			// the first preview line is the post title
+			r.out.WriteString(highlight(fitText(line, rightWidth), false, false, idx == 0))
+		}
+		r.out.WriteString("\x1b[K")
+	}
+	fmt.Fprintf(r.out, "\x1b[%d;1H\x1b[2m%s\x1b[0m\x1b[K", height, fitText(" "+readerHelp, width))
+	r.out.Flush()
+}
+
+func (r *reader) feedCell(idx int, width int) string {
+	if idx >= len(r.feeds) {
+		return strings.Repeat(" ", width)
+	}
+	feed := r.feeds[idx]
+	label := " " + feed.name
+	if feed.unread > 0 {
+		label = fmt.Sprintf("%s (%d)", label, feed.unread)
+	}
+	return highlight(fitText(label, width), idx == r.feedCursor, r.focus == paneFeeds, feed.unread > 0)
+}
+
+func (r *reader) postCell(idx int, width int) string {
+	if len(r.posts) == 0 && idx == 0 {
+		return fitText(" No posts yet", width)
+	}
+	if idx >= len(r.posts) {
+		return strings.Repeat(" ", width)
+	}
+	post := r.posts[idx]
+	marker := "  "
+	switch {
+	case post.starred:
+		marker = "★ "
+	case post.highlighted:
+		marker = "◆ "
+	case !post.read:
+		marker = "● "
+	}
+	date := post.publishedAt.Format("Jan 02")
+	titleWidth := max(width-utf8.RuneCountInString(date)-4, 1)
+	label := " " + marker + fitText(post.title, titleWidth) + date
+	return highlight(fitText(label, width), idx == r.postCursor, r.focus == panePosts, !post.read)
+}
+
+func (r *reader) previewLines(width int) []string {
+	post := r.currentPost()
+	if post == nil {
+		return nil
+	}
+	header := " " + post.feedName + " · " + relativeTime(post.publishedAt, time.Now())
+	if post.highlighted {
+		header += " · highlighted"
+	}
+	lines := []string{
+		" " + post.title,
+		header,
+		" " + post.url,
+		"",
+	}
+	if text := postBody(post.description, post.content, r.fullContent); text != "" {
+		lines = append(lines, strings.Split(wrapText(text, width-1, " "), "\n")...)
+	}
+	return lines
+}
+
+func highlight(text string, selected, focused, bold bool) string {
+	switch {
+	case selected && focused:
+		return "\x1b[7m" + text + "\x1b[0m"
+	case selected:
+		return "\x1b[4m" + text + "\x1b[0m"
+	case bold:
+		return "\x1b[1m" + text + "\x1b[0m"
+	}
+	return text
+}
+
+// fitText truncates or pads s to exactly width runes.
+func fitText(s string, width int) string {
+	runes := []rune(strings.ReplaceAll(s, "\t", " "))
+	if len(runes) > width {
+		runes = append(runes[:max(width-1, 0)], '…')
+	}
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
+
+func scrollOffset(cursor, offset, height int) int {
+	if cursor < offset {
+		return cursor
+	}
+	if cursor >= offset+height {
+		return cursor - height + 1
+	}
+	return offset
+}
+
+func clamp(value, low, high int) int {
+	if high < low {
+		return low
+	}
+	return min(max(value, low), high)
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/term"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+const (
+	defaultTerminalWidth = 80
+	maxTerminalWidth     = 100
+	summaryIndent        = "    "
+	maxSummaryLength     = 320
+)
+
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return min(width, maxTerminalWidth)
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return min(columns, maxTerminalWidth)
+	}
+	return defaultTerminalWidth
+}
+
+// localTime converts a stored UTC timestamp to the configured display
+// timezone.
+func (s *state) localTime(t time.Time) time.Time {
+	return t.In(s.location)
+}
+
+// showFullContent reports whether posts should show the full article,
+// from the --full flag if given and the config otherwise.
+func (s *state) showFullContent(cmd command) bool {
+	if cmd.flagPassed("full") {
+		return cmd.boolFlag("full")
+	}
+	return s.cfg.FullContent
+}
+
+// postDetails holds what browse shows under a post besides its text.
+type postDetails struct {
+	enclosures  []database.Enclosure
+	tags        []string
+	highlighted bool
+}
+
+func printPosts(w io.Writer, posts []database.GetPostsForUserRow, details map[int64]postDetails, location *time.Location, full bool) {
+	width := terminalWidth()
+	now := time.Now()
+	for idx, post := range posts {
+		if idx > 0 {
+			fmt.Fprintln(w)
+		}
+		header := fmt.Sprintf("#%d · %s · %s", post.ID, post.FeedName, relativeTime(post.PublishedAt.In(location), now))
+		if post.Author != "" {
+			header += " · " + post.Author
+		}
+		if details[post.ID].highlighted {
+			header += " · highlighted"
+		}
+		fmt.Fprintln(w, header)
+		fmt.Fprintln(w, post.Title)
+		fmt.Fprintln(w, post.Url)
+		for _, enc := range details[post.ID].enclosures {
+			fmt.Fprintln(w, "Enclosure: "+describeEnclosure(enc))
+		}
+		if tags := details[post.ID].tags; len(tags) > 0 {
+			fmt.Fprintln(w, "Tags: "+strings.Join(tags, ", "))
+		}
+		body := postBody(post.Description, post.Content, full)
+		if !full {
+			body = truncateText(body, maxSummaryLength)
+		}
+		if body != "" {
+			fmt.Fprintln(w, wrapText(body, width, summaryIndent))
+		}
+	}
+}
+
+func relativeTime(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "unknown date"
+	}
+	elapsed := now.Sub(t)
+	switch {
+	case elapsed < 0:
+		return t.Format("Jan 2, 2006")
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return pluralize(int(elapsed/time.Minute), "minute") + " ago"
+	case elapsed < 24*time.Hour:
+		return pluralize(int(elapsed/time.Hour), "hour") + " ago"
+	case elapsed < 30*24*time.Hour:
+		return pluralize(int(elapsed/(24*time.Hour)), "day") + " ago"
+	default:
+		return t.Format("Jan 2, 2006")
+	}
+}
+
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// postBody returns a post's text for display. The full view shows the
+// article when the feed provided one, with links as footnotes; the summary
+// view falls back to the content for feeds that only provide that.
+func postBody(description, content string, full bool) string {
+	if full {
+		if content != "" {
+			return htmlToReadableText(content)
+		}
+		return htmlToReadableText(description)
+	}
+	if summary := htmlToText(description); summary != "" {
+		return summary
+	}
+	return htmlToText(content)
+}
+
+// htmlToText converts HTML to terminal text: block elements become line
+// breaks, list items get bullets or numbers, and links keep only their
+// text. Use it where the text may be truncated.
+func htmlToText(s string) string {
+	return convertHTML(s, false)
+}
+
+// htmlToReadableText is htmlToText with every web link numbered in the text
+// and listed as a footnote at the end.
+func htmlToReadableText(s string) string {
+	return convertHTML(s, true)
+}
+
+func convertHTML(s string, footnotes bool) string {
+	// blocks holds the text converted so far: runs of ordinary text with
+	// their whitespace collapsed, and <pre> contents as they were.
+	var blocks []string
+	var b strings.Builder
+	var lists []int // next item number per open list, 0 for bullets
+	var links []string
+	var href string
+	linkStart := 0
+	skip, pre := 0, 0
+	flush := func(verbatim bool) {
+		text := b.String()
+		if verbatim {
+			text = strings.TrimRight(strings.TrimLeft(text, "\n"), " \t\n")
+		} else {
+			text = collapseWhitespace(text)
+		}
+		if text != "" {
+			blocks = append(blocks, text)
+		}
+		b.Reset()
+		linkStart = 0
+	}
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			flush(pre > 0)
+			text := strings.Join(blocks, "\n")
+			for idx, link := range links {
+				text += fmt.Sprintf("\n[%d] %s", idx+1, link)
+			}
+			return text
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			text := string(tokenizer.Text())
+			if pre == 0 {
+				text = strings.ReplaceAll(text, "\n", " ")
+			}
+			b.WriteString(text)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			attrs := make(map[string]string)
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+			switch string(name) {
+			case "script", "style":
+				if tokenType == html.StartTagToken {
+					skip++
+				}
+			case "pre":
+				if pre == 0 {
+					flush(false)
+				}
+				pre++
+			case "ul":
+				lists = append(lists, 0)
+				b.WriteString("\n")
+			case "ol":
+				start, err := strconv.Atoi(attrs["start"])
+				if err != nil {
+					start = 1
+				}
+				lists = append(lists, start)
+				b.WriteString("\n")
+			case "li":
+				b.WriteString("\n")
+				if len(lists) == 0 || lists[len(lists)-1] == 0 {
+					b.WriteString("• ")
+				} else {
+					fmt.Fprintf(&b, "%d. ", lists[len(lists)-1])
+					lists[len(lists)-1]++
+				}
+			case "br", "p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote", "tr", "dt", "dd", "figure", "figcaption", "hr", "table":
+				b.WriteString("\n")
+			case "img":
+				if alt := strings.TrimSpace(attrs["alt"]); alt != "" {
+					b.WriteString("[" + alt + "]")
+				}
+			case "a":
+				href, linkStart = "", b.Len()
+				if footnotes && (strings.HasPrefix(attrs["href"], "http://") || strings.HasPrefix(attrs["href"], "https://")) {
+					href = attrs["href"]
+				}
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "script", "style":
+				if skip > 0 {
+					skip--
+				}
+			case "pre":
+				if pre > 0 {
+					pre--
+					if pre == 0 {
+						flush(true)
+					}
+				}
+			case "ul", "ol":
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+				b.WriteString("\n")
+			case "p", "div", "li", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote", "tr", "dd", "figure", "table":
+				b.WriteString("\n")
+			case "a":
+				if href != "" && strings.TrimSpace(b.String()[linkStart:]) != href {
+					number := slices.Index(links, href) + 1
+					if number == 0 {
+						links = append(links, href)
+						number = len(links)
+					}
+					fmt.Fprintf(&b, "[%d]", number)
+				}
+				href = ""
+			}
+		}
+	}
+}
+
+func collapseWhitespace(s string) string {
+	var paragraphs []string
+	for _, line := range strings.Split(s, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			paragraphs = append(paragraphs, strings.Join(fields, " "))
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+func truncateText(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)[:limit]
+	if cut := strings.LastIndexAny(string(runes), " \n"); cut > 0 {
+		return strings.TrimSpace(string(runes)[:cut]) + "…"
+	}
+	return string(runes) + "…"
+}
+
+func wrapText(s string, width int, indent string) string {
+	limit := width - utf8.RuneCountInString(indent)
+	if limit < 20 {
+		limit = 20
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		paragraph = strings.TrimRight(paragraph, " \t")
+		// Lines that fit are left alone, so preformatted text keeps its
+		// indentation and alignment.
+		if paragraph != "" && utf8.RuneCountInString(paragraph) <= limit {
+			lines = append(lines, indent+paragraph)
+			continue
+		}
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > limit {
+				lines = append(lines, indent+line)
+				line = ""
+			}
+			if line == "" {
+				line = word
+			} else {
+				line += " " + word
+			}
+		}
+		if line != "" {
+			lines = append(lines, indent+line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestHTMLToTextKeepsPreformattedText(t *testing.T) {
+	input := "<p>Run   this:</p><pre>\nfunc main() {\n\tif ok {\n\t\tfmt.Println(\"a  b\")\n\t}\n}\n</pre><p>and\n  see.</p>"
+	want := "Run this:\nfunc main() {\n\tif ok {\n\t\tfmt.Println(\"a  b\")\n\t}\n}\nand see."
+	text := htmlToText(input)
+	if text != want {
+		t.Errorf("htmlToText() = %q, want %q", text, want)
+	}
+	wantWrapped := "  Run this:\n  func main() {\n  \tif ok {\n  \t\tfmt.Println(\"a  b\")\n  \t}\n  }\n  and see."
+	if wrapped := wrapText(text, 72, "  "); wrapped != wantWrapped {
+		t.Errorf("wrapText() = %q, want %q", wrapped, wantWrapped)
+	}
+}
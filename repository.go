@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+// repository is the storage behind the core commands: users, the feeds
+// they add and follow, and the posts fetched from those feeds. The sqlc
+// Queries implement it on Postgres and SQLite, and memstore.Store keeps it
+// in memory for tests. Everything else goes through state.db.
+type repository interface {
+	userRepository
+	feedRepository
+	followRepository
+	postRepository
+}
+
+type userRepository interface {
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	GetUser(ctx context.Context, name string) (database.User, error)
+	GetUserFromID(ctx context.Context, id int64) (database.User, error)
+	GetUsers(ctx context.Context) ([]string, error)
+	ResetUsers(ctx context.Context) error
+}
+
+type feedRepository interface {
+	CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error)
+	GetAllFeeds(ctx context.Context) ([]database.Feed, error)
+	GetFeedFromURL(ctx context.Context, url string) (database.Feed, error)
+	GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error)
+	GetFeedsByName(ctx context.Context, name string) ([]database.Feed, error)
+	GetNextFeedToFetch(ctx context.Context) (database.Feed, error)
+	MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error
+}
+
+type followRepository interface {
+	CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error)
+	DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error
+	GetFeedFollowsForUser(ctx context.Context, userID int64) ([]database.GetFeedFollowsForUserRow, error)
+	SetFeedFollowDisplayName(ctx context.Context, arg database.SetFeedFollowDisplayNameParams) (int64, error)
+}
+
+type postRepository interface {
+	CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error)
+	GetPostByID(ctx context.Context, id int64) (database.GetPostByIDRow, error)
+	GetPostsForUserFeed(ctx context.Context, arg database.GetPostsForUserFeedParams) ([]database.GetPostsForUserFeedRow, error)
+	MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error
+	PostExists(ctx context.Context, url string) (bool, error)
+	SetPostStarred(ctx context.Context, arg database.SetPostStarredParams) error
+}
+
+var _ repository = (*database.Queries)(nil)
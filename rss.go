@@ -3,13 +3,25 @@ package main
 import (
 	"context"
 	"encoding/xml"
-	"html"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
 )
 
+// The XMLBase fields here and in the Atom types hold xml:base, which sets
+// the base URL for relative links inside the element that carries it.
 type RSSFeed struct {
+	XMLBase string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 	Channel struct {
+		XMLBase     string    `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 		Title       string    `xml:"title"`
 		Link        string    `xml:"link"`
 		Description string    `xml:"description"`
@@ -17,11 +29,107 @@ type RSSFeed struct {
 	} `xml:"channel"`
 }
 
+// RSSItem keeps the short description and the full article body apart;
+// many feeds only put a teaser in description and the article itself in
+// content:encoded. Media files come from <enclosure>, Media RSS and the
+// iTunes podcast extension; enclosures merges them.
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	XMLBase        string         `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title          string         `xml:"title"`
+	Link           string         `xml:"link"`
+	Description    string         `xml:"description"`
+	Content        string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate        string         `xml:"pubDate"`
+	Author         string         `xml:"author"`
+	Creator        string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories     []string       `xml:"category"`
+	Enclosures     []rssEnclosure `xml:"enclosure"`
+	MediaContent   []rssEnclosure `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroups    []mediaGroup   `xml:"http://search.yahoo.com/mrss/ group"`
+	ITunesDuration string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+}
+
+// rssEnclosure holds both <enclosure> (url, type, length) and
+// <media:content> (url, type, fileSize, duration) attributes.
+type rssEnclosure struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Length   string `xml:"length,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type mediaGroup struct {
+	Content []rssEnclosure `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
+// enclosure is a media file attached to a feed item. Length is in bytes
+// and Duration in seconds; zero means the feed did not say.
+type enclosure struct {
+	URL      string
+	MimeType string
+	Length   int64
+	Duration int
+}
+
+// atomFeed is parsed separately and converted to an RSSFeed so the rest of
+// gator only deals with one shape.
+type atomFeed struct {
+	XMLBase  string       `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title    atomText     `xml:"title"`
+	Subtitle atomText     `xml:"subtitle"`
+	Links    []atomLink   `xml:"link"`
+	Authors  []atomPerson `xml:"author"`
+	Entries  []atomEntry  `xml:"entry"`
+}
+
+type atomEntry struct {
+	XMLBase   string     `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title     atomText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	// Authors falls back to the feed's authors when the entry has none.
+	Authors []atomPerson `xml:"author"`
+	// Categories carry the entry's tags; term is required, label optional.
+	Categories []struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	} `xml:"category"`
+}
+
+type atomLink struct {
+	XMLBase string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Href    string `xml:"href,attr"`
+	Rel     string `xml:"rel,attr"`
+	Type    string `xml:"type,attr"`
+	Length  string `xml:"length,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	XMLBase string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+	Inner   string `xml:",innerxml"`
+}
+
+// pubDateLayouts covers RFC 822 dates as feeds actually write them, plus
+// the RFC 3339 dates Atom uses.
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
 }
 
 func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
@@ -42,12 +150,30 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	if err != nil {
 		return &feed, err
 	}
-	if err := xml.Unmarshal(data, &feed); err != nil {
+
+	// Relative links resolve against the feed's final URL after redirects.
+	base := resp.Request.URL
+
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
 		return &RSSFeed{}, err
 	}
-
-	cleanedFeed := htmlCleanup(&feed)
-	return cleanedFeed, nil
+	switch root.XMLName.Local {
+	case "rss":
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return &RSSFeed{}, err
+		}
+		return htmlCleanup(&feed).resolveLinks(base), nil
+	case "feed":
+		var atom atomFeed
+		if err := xml.Unmarshal(data, &atom); err != nil {
+			return &RSSFeed{}, err
+		}
+		return atom.toRSS(base), nil
+	}
+	return &RSSFeed{}, fmt.Errorf("unsupported feed format <%s>", root.XMLName.Local)
 }
 
 func htmlCleanup(feed *RSSFeed) *RSSFeed {
@@ -60,3 +186,278 @@ func htmlCleanup(feed *RSSFeed) *RSSFeed {
 
 	return feed
 }
+
+// resolveLinks makes item links and the href and src attributes in
+// descriptions and content absolute, honouring xml:base on the document,
+// channel and item.
+func (feed *RSSFeed) resolveLinks(base *url.URL) *RSSFeed {
+	base = withXMLBase(withXMLBase(base, feed.XMLBase), feed.Channel.XMLBase)
+	feed.Channel.Link = resolveURL(base, feed.Channel.Link)
+	for idx, item := range feed.Channel.Items {
+		itemBase := withXMLBase(base, item.XMLBase)
+		feed.Channel.Items[idx].Link = resolveURL(itemBase, item.Link)
+		feed.Channel.Items[idx].Description = resolveHTMLLinks(item.Description, itemBase)
+		feed.Channel.Items[idx].Content = resolveHTMLLinks(item.Content, itemBase)
+		resolveEnclosures(item.Enclosures, itemBase)
+		resolveEnclosures(item.MediaContent, itemBase)
+		for _, group := range item.MediaGroups {
+			resolveEnclosures(group.Content, itemBase)
+		}
+	}
+	return feed
+}
+
+func (a atomFeed) toRSS(base *url.URL) *RSSFeed {
+	var feed RSSFeed
+	base = withXMLBase(base, a.XMLBase)
+	feed.Channel.Title = a.Title.plain()
+	feed.Channel.Link = alternateLink(a.Links, base)
+	feed.Channel.Description = a.Subtitle.plain()
+	for _, entry := range a.Entries {
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+		entryBase := withXMLBase(base, entry.XMLBase)
+		item := RSSItem{
+			Title:       entry.Title.plain(),
+			Link:        alternateLink(entry.Links, entryBase),
+			Description: resolveHTMLLinks(entry.Summary.html(), withXMLBase(entryBase, entry.Summary.XMLBase)),
+			Content:     resolveHTMLLinks(entry.Content.html(), withXMLBase(entryBase, entry.Content.XMLBase)),
+			PubDate:     published,
+		}
+		authors := entry.Authors
+		if len(authors) == 0 {
+			authors = a.Authors
+		}
+		var names []string
+		for _, author := range authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				names = append(names, name)
+			}
+		}
+		item.Creator = strings.Join(names, ", ")
+		for _, category := range entry.Categories {
+			if category.Term != "" {
+				item.Categories = append(item.Categories, category.Term)
+			} else {
+				item.Categories = append(item.Categories, category.Label)
+			}
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" {
+				item.Enclosures = append(item.Enclosures, rssEnclosure{
+					URL:    resolveURL(withXMLBase(entryBase, link.XMLBase), link.Href),
+					Type:   link.Type,
+					Length: link.Length,
+				})
+			}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return &feed
+}
+
+// enclosures returns the item's media files, first seen wins when the same
+// URL appears more than once. An iTunes duration applies to the first
+// enclosure, which is the episode itself in podcast feeds.
+func (item RSSItem) enclosures() []enclosure {
+	all := append([]rssEnclosure(nil), item.Enclosures...)
+	all = append(all, item.MediaContent...)
+	for _, group := range item.MediaGroups {
+		all = append(all, group.Content...)
+	}
+	var out []enclosure
+	seen := make(map[string]bool)
+	for _, raw := range all {
+		link := strings.TrimSpace(raw.URL)
+		if link == "" || seen[link] {
+			continue
+		}
+		seen[link] = true
+		length := raw.Length
+		if length == "" {
+			length = raw.FileSize
+		}
+		size, _ := strconv.ParseInt(strings.TrimSpace(length), 10, 64)
+		mimeType := strings.TrimSpace(raw.Type)
+		if mimeType == "" {
+			if parsed, err := url.Parse(link); err == nil {
+				mimeType, _, _ = strings.Cut(mime.TypeByExtension(path.Ext(parsed.Path)), ";")
+			}
+		}
+		out = append(out, enclosure{
+			URL:      link,
+			MimeType: mimeType,
+			Length:   max(size, 0),
+			Duration: parseDuration(raw.Duration),
+		})
+	}
+	if len(out) > 0 && out[0].Duration == 0 {
+		out[0].Duration = parseDuration(item.ITunesDuration)
+	}
+	return out
+}
+
+// author prefers dc:creator, which holds a name, over <author>, which RSS
+// defines as an email address optionally followed by the name in
+// parentheses.
+func (item RSSItem) author() string {
+	if creator := strings.TrimSpace(html.UnescapeString(item.Creator)); creator != "" {
+		return creator
+	}
+	author := strings.TrimSpace(html.UnescapeString(item.Author))
+	if open := strings.Index(author, "("); open >= 0 && strings.HasSuffix(author, ")") {
+		if name := strings.TrimSpace(author[open+1 : len(author)-1]); name != "" {
+			return name
+		}
+	}
+	return author
+}
+
+// tags returns the item's categories with whitespace collapsed, dropping
+// empty ones and case-insensitive duplicates.
+func (item RSSItem) tags() []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, category := range item.Categories {
+		tag := strings.Join(strings.Fields(html.UnescapeString(category)), " ")
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func resolveEnclosures(enclosures []rssEnclosure, base *url.URL) {
+	for idx, enc := range enclosures {
+		enclosures[idx].URL = resolveURL(base, enc.URL)
+	}
+}
+
+// parseDuration reads a duration in seconds from the forms podcast feeds
+// use: "1:02:03", "62:03" or plain seconds, possibly fractional. It returns
+// zero when the value is missing or malformed.
+func parseDuration(value string) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0
+	}
+	total := 0.0
+	for _, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		total = total*60 + n
+	}
+	return int(total)
+}
+
+// html returns the construct as HTML: xhtml content is taken verbatim,
+// escaped html content has already been decoded by the XML parser, and
+// plain text is escaped.
+func (t atomText) html() string {
+	switch t.Type {
+	case "xhtml":
+		return strings.TrimSpace(t.Inner)
+	case "html":
+		return strings.TrimSpace(t.Text)
+	default:
+		return html.EscapeString(strings.TrimSpace(t.Text))
+	}
+}
+
+func (t atomText) plain() string {
+	if t.Type == "text" || t.Type == "" {
+		return strings.TrimSpace(t.Text)
+	}
+	return htmlToText(t.html())
+}
+
+func alternateLink(links []atomLink, base *url.URL) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return resolveURL(withXMLBase(base, link.XMLBase), link.Href)
+		}
+	}
+	if len(links) > 0 {
+		return resolveURL(withXMLBase(base, links[0].XMLBase), links[0].Href)
+	}
+	return ""
+}
+
+// withXMLBase applies an xml:base value, which may itself be relative, to
+// the base URL in effect for the parent element.
+func withXMLBase(base *url.URL, xmlBase string) *url.URL {
+	xmlBase = strings.TrimSpace(xmlBase)
+	if xmlBase == "" {
+		return base
+	}
+	parsed, err := url.Parse(xmlBase)
+	if err != nil {
+		return base
+	}
+	if base == nil {
+		return parsed
+	}
+	return base.ResolveReference(parsed)
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ref
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(parsed).String()
+}
+
+// resolveHTMLLinks rewrites relative href, src and cite attributes in an
+// HTML fragment against base and leaves everything else untouched.
+func resolveHTMLLinks(s string, base *url.URL) string {
+	if base == nil || !strings.Contains(s, "=") {
+		return s
+	}
+	var b strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return b.String()
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			b.Write(tokenizer.Raw())
+			continue
+		}
+		token := tokenizer.Token()
+		for idx, attr := range token.Attr {
+			if urlAttrs[attr.Key] {
+				token.Attr[idx].Val = resolveURL(base, attr.Val)
+			}
+		}
+		b.WriteString(token.String())
+	}
+}
+
+// parsePubDate accepts the date formats feeds use in practice and returns
+// the zero time when none match.
+func parsePubDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range pubDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags maps each element kept in stored HTML to the attributes it
+// may carry. Anything else is dropped, keeping its text.
+var allowedTags = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        nil,
+	"div":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        nil,
+	"li":         nil,
+	"ol":         {"start"},
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"small":      nil,
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan"},
+	"thead":      nil,
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// droppedTags are removed together with everything inside them.
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "form": true, "svg": true, "math": true,
+	"head": true, "title": true, "select": true, "textarea": true, "button": true,
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+// implicitlyClosed elements end when a sibling of the same kind starts, as
+// in "<li>one<li>two"; scopeTags bound how far up that search goes.
+var implicitlyClosed = map[string]bool{"p": true, "li": true, "dt": true, "dd": true, "tr": true, "td": true, "th": true}
+
+var scopeTags = map[string]bool{"ul": true, "ol": true, "dl": true, "table": true, "blockquote": true, "div": true}
+
+var urlAttrs = map[string]bool{"href": true, "src": true, "cite": true}
+
+var trackerURL = regexp.MustCompile(`(?i)feedburner\.com/~[rf]/|feeds\.feedblitz\.com/~/i/|pixel\.wp\.com|stats\.wordpress\.com|doubleclick\.net|/open\.gif|/pixel\.(gif|png)`)
+
+// sanitizeHTML rewrites feed HTML so only allowlisted elements and
+// attributes survive. Scripts, styles, embeds, event handlers, inline
+// styles, non-http URLs and tracking pixels are removed and unclosed
+// elements are closed, so the result is safe to store and re-render.
+func sanitizeHTML(s string) string {
+	var b strings.Builder
+	var open []string
+	skip := 0
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		name := token.Data
+		switch tokenType {
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(token.Data))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedTags[name] {
+				if tokenType == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			allowed, ok := allowedTags[name]
+			if skip > 0 || !ok {
+				continue
+			}
+			attrs := sanitizeAttrs(token.Attr, allowed)
+			if name == "img" && isTrackingPixel(attrs) {
+				continue
+			}
+			if implicitlyClosed[name] {
+				for idx := len(open) - 1; idx >= 0 && !scopeTags[open[idx]]; idx-- {
+					if open[idx] == name {
+						closeTags(&b, open[idx:])
+						open = open[:idx]
+						break
+					}
+				}
+			}
+			b.WriteString("<" + name)
+			for _, attr := range attrs {
+				b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+			}
+			if name == "a" && slices.ContainsFunc(attrs, func(attr html.Attribute) bool { return attr.Key == "href" }) {
+				b.WriteString(` rel="nofollow noopener"`)
+			}
+			b.WriteString(">")
+			if !voidTags[name] && tokenType == html.StartTagToken {
+				open = append(open, name)
+			}
+		case html.EndTagToken:
+			if droppedTags[name] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			for idx := len(open) - 1; idx >= 0; idx-- {
+				if open[idx] == name {
+					closeTags(&b, open[idx:])
+					open = open[:idx]
+					break
+				}
+			}
+		}
+	}
+	closeTags(&b, open)
+	return strings.TrimSpace(b.String())
+}
+
+func sanitizeAttrs(attrs []html.Attribute, allowed []string) []html.Attribute {
+	var kept []html.Attribute
+	for _, attr := range attrs {
+		if attr.Namespace != "" || !slices.Contains(allowed, attr.Key) {
+			continue
+		}
+		if urlAttrs[attr.Key] {
+			safe, ok := safeURL(attr.Val)
+			if !ok {
+				continue
+			}
+			attr.Val = safe
+		}
+		kept = append(kept, attr)
+	}
+	return kept
+}
+
+// safeURL accepts http, https and mailto URLs and relative references.
+func safeURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return raw, true
+	}
+	return "", false
+}
+
+func isTrackingPixel(attrs []html.Attribute) bool {
+	src, width, height := "", -1, -1
+	for _, attr := range attrs {
+		switch attr.Key {
+		case "src":
+			src = attr.Val
+		case "width", "height":
+			size, err := strconv.Atoi(strings.TrimSuffix(attr.Val, "px"))
+			if err != nil {
+				continue
+			}
+			if attr.Key == "width" {
+				width = size
+			} else {
+				height = size
+			}
+		}
+	}
+	if src == "" {
+		return true
+	}
+	if width >= 0 && width <= 1 && height >= 0 && height <= 1 {
+		return true
+	}
+	return trackerURL.MatchString(src)
+}
+
+// closeTags writes end tags for the open elements, innermost first.
+func closeTags(b *strings.Builder, open []string) {
+	for idx := len(open) - 1; idx >= 0; idx-- {
+		b.WriteString("</" + open[idx] + ">")
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+type savedSearchRecord struct {
+	Name       string `json:"name"`
+	Query      string `json:"query,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	Folder     string `json:"folder,omitempty"`
+	WithinDays int32  `json:"within_days,omitempty"`
+}
+
+// searchCriteria is what a search matches on, whether typed on the
+// command line or loaded from a saved search.
+type searchCriteria struct {
+	query      string
+	tag        string
+	folder     string
+	withinDays int32
+}
+
+// searchPattern turns a search query into a LIKE pattern that matches the
+// query anywhere, with LIKE's wildcards taken literally. An empty query
+// gives an empty pattern, which matches every post.
+func searchPattern(query string) string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + escaper.Replace(query) + "%"
+}
+
+// postsParams builds the browse query for criteria. Searches limited to a
+// number of days count back from now, so they are evaluated afresh each
+// time.
+func (c searchCriteria) postsParams(ctx context.Context, s *state, user database.User, limit int32) (database.GetPostsForUserParams, error) {
+	params := database.GetPostsForUserParams{
+		UserID:   user.ID,
+		Tag:      c.tag,
+		Pattern:  searchPattern(c.query),
+		RowLimit: limit,
+	}
+	if c.folder != "" {
+		folder, err := getFolder(ctx, s, user, c.folder)
+		if err != nil {
+			return database.GetPostsForUserParams{}, err
+		}
+		params.FolderID = folder.ID
+	}
+	if c.withinDays > 0 {
+		params.PublishedAfter = time.Now().UTC().AddDate(0, 0, -int(c.withinDays))
+	}
+	return params, nil
+}
+
+func (c searchCriteria) String() string {
+	var parts []string
+	if c.query != "" {
+		parts = append(parts, strconv.Quote(c.query))
+	}
+	if c.tag != "" {
+		parts = append(parts, "tag "+c.tag)
+	}
+	if c.folder != "" {
+		parts = append(parts, "in "+c.folder+"/")
+	}
+	if c.withinDays > 0 {
+		parts = append(parts, "last "+pluralize(int(c.withinDays), "day"))
+	}
+	if len(parts) == 0 {
+		return "all posts"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func savedSearchCriteria(search database.GetSavedSearchesForUserRow) searchCriteria {
+	return searchCriteria{
+		query:      search.Query,
+		tag:        search.Tag,
+		folder:     search.FolderName.String,
+		withinDays: search.WithinDays.Int32,
+	}
+}
+
+// saveSearch stores criteria under name for the user, replacing any
+// search already saved under that name.
+func saveSearch(ctx context.Context, s *state, user database.User, name string, criteria searchCriteria) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("saved search name is empty")
+	}
+	var folderID sql.NullInt64
+	if criteria.folder != "" {
+		folder, err := getFolder(ctx, s, user, criteria.folder)
+		if err != nil {
+			return err
+		}
+		folderID = sql.NullInt64{Int64: folder.ID, Valid: true}
+	}
+	now := time.Now().UTC()
+	if _, err := s.db.SaveSearch(ctx, database.SaveSearchParams{
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		UserID:     user.ID,
+		Name:       name,
+		Query:      strings.TrimSpace(criteria.query),
+		Tag:        criteria.tag,
+		FolderID:   folderID,
+		WithinDays: sql.NullInt32{Int32: criteria.withinDays, Valid: criteria.withinDays > 0},
+	}); err != nil {
+		return err
+	}
+	// The search results follow on stdout, so the note goes to stderr to
+	// keep --output json and csv parseable.
+	fmt.Fprintf(os.Stderr, "Saved search %s: %s\n", name, criteria)
+	return nil
+}
+
+// getSavedSearch looks up one of the user's saved searches by name.
+func getSavedSearch(ctx context.Context, s *state, user database.User, name string) (searchCriteria, error) {
+	search, err := s.db.GetSavedSearchByName(ctx, database.GetSavedSearchByNameParams{UserID: user.ID, Name: strings.TrimSpace(name)})
+	if errors.Is(err, sql.ErrNoRows) {
+		return searchCriteria{}, fmt.Errorf("no saved search named %q", name)
+	}
+	if err != nil {
+		return searchCriteria{}, err
+	}
+	criteria := searchCriteria{query: search.Query, tag: search.Tag, withinDays: search.WithinDays.Int32}
+	if search.FolderID.Valid {
+		folders, err := s.db.GetFoldersForUser(ctx, user.ID)
+		if err != nil {
+			return searchCriteria{}, err
+		}
+		for _, folder := range folders {
+			if folder.ID == search.FolderID.Int64 {
+				criteria.folder = folder.Name
+			}
+		}
+	}
+	return criteria, nil
+}
+
+// commandSearches lists the current user's saved searches, or deletes one
+// with "searches delete <name>".
+func commandSearches(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	if len(cmd.arguments) > 0 {
+		if cmd.arguments[0] != "delete" || len(cmd.arguments) != 2 {
+			return errors.New("usage: searches [delete <name>]")
+		}
+		deleted, err := s.db.DeleteSavedSearch(ctx, database.DeleteSavedSearchParams{UserID: user.ID, Name: strings.TrimSpace(cmd.arguments[1])})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return fmt.Errorf("no saved search named %q", cmd.arguments[1])
+		}
+		fmt.Printf("Deleted saved search %s\n", cmd.arguments[1])
+		return nil
+	}
+
+	searches, err := s.db.GetSavedSearchesForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	records := make([]savedSearchRecord, 0, len(searches))
+	rows := make([][]string, 0, len(searches))
+	for _, search := range searches {
+		records = append(records, savedSearchRecord{
+			Name:       search.Name,
+			Query:      search.Query,
+			Tag:        search.Tag,
+			Folder:     search.FolderName.String,
+			WithinDays: search.WithinDays.Int32,
+		})
+		withinDays := ""
+		if search.WithinDays.Valid {
+			withinDays = strconv.Itoa(int(search.WithinDays.Int32))
+		}
+		rows = append(rows, []string{search.Name, search.Query, search.Tag, search.FolderName.String, withinDays})
+	}
+	return s.render(listing{
+		columns: []string{"name", "query", "tag", "folder", "within_days"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			if len(searches) == 0 {
+				fmt.Fprintln(w, "No saved searches")
+				return
+			}
+			for _, search := range searches {
+				fmt.Fprintf(w, "%s: %s\n", search.Name, savedSearchCriteria(search))
+			}
+		},
+	})
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	shellHistoryFileName = ".gator_history"
+	shellHistoryLimit    = 1000
+)
+
+// commandShell keeps one state alive and dispatches each input line through
+// the command registry until exit, quit or EOF.
+func (c *commands) commandShell(s *state, cmd command) error {
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) {
+		return c.runScript(s, os.Stdin)
+	}
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "")
+	terminal.History = loadShellHistory()
+	terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return c.completeLine(s, terminal, line, pos)
+	}
+
+	fmt.Println(`gator shell - type "help" for commands, "exit" to quit`)
+	for {
+		terminal.SetPrompt(shellPrompt(s))
+		oldState, err := term.MakeRaw(stdin)
+		if err != nil {
+			return err
+		}
+		line, err := terminal.ReadLine()
+		term.Restore(stdin, oldState)
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !c.runLine(s, line) {
+			return nil
+		}
+	}
+}
+
+func (c *commands) runScript(s *state, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if !c.runLine(s, scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// runLine executes one shell line and reports whether the shell should keep
+// reading input.
+func (c *commands) runLine(s *state, line string) bool {
+	words, err := splitCommandLine(line)
+	if err != nil {
+		fmt.Println(err)
+		return true
+	}
+	if len(words) == 0 || strings.HasPrefix(words[0], "#") {
+		return true
+	}
+	format, args, err := parseGlobalOptions(words)
+	if err != nil {
+		fmt.Println(err)
+		return true
+	}
+	if len(args) == 0 {
+		return true
+	}
+	switch args[0] {
+	case "exit", "quit":
+		return false
+	case "shell":
+		fmt.Println("already in the gator shell")
+		return true
+	}
+
+	defaultFormat := s.output
+	if len(args) < len(words) {
+		s.output = format
+	}
+	if err := c.run(s, command{name: args[0], arguments: args[1:]}); err != nil {
+		fmt.Println(err)
+	}
+	s.output = defaultFormat
+	return true
+}
+
+func shellPrompt(s *state) string {
+	if s.cfg.Username == "" {
+		return "gator> "
+	}
+	return fmt.Sprintf("gator (%s)> ", s.cfg.Username)
+}
+
+// completeLine completes the word under the cursor. With several candidates
+// it extends to their common prefix, or lists them when it cannot.
+func (c *commands) completeLine(s *state, terminal *term.Terminal, line string, pos int) (string, int, bool) {
+	prefix, suffix := line[:pos], line[pos:]
+	words := strings.Fields(prefix)
+	if len(words) == 0 || strings.HasSuffix(prefix, " ") {
+		words = append(words, "")
+	}
+	current := words[len(words)-1]
+	_, words, _ = parseGlobalOptions(words)
+
+	var candidates []string
+	switch {
+	case len(words) <= 1:
+		candidates = append(c.visibleNames(), "exit")
+	default:
+		spec, ok := c.commandMap[words[0]]
+		if !ok {
+			return "", 0, false
+		}
+		if strings.HasPrefix(current, "-") {
+			candidates = spec.flagNames()
+			break
+		}
+		position := 0
+		for _, word := range words[1 : len(words)-1] {
+			if !strings.HasPrefix(word, "-") {
+				position++
+			}
+		}
+		if position >= len(spec.args) {
+			return "", 0, false
+		}
+		all, err := c.completionCandidates(context.Background(), s, spec.args[position])
+		if err != nil {
+			return "", 0, false
+		}
+		candidates = all
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+	base := prefix[:len(prefix)-len(current)]
+	if len(matches) == 1 {
+		completed := base + quoteWord(matches[0]) + " "
+		return completed + suffix, len(completed), true
+	}
+	common := commonPrefix(matches)
+	if len(common) > len(current) && !strings.ContainsAny(common, " \t'\"") {
+		completed := base + common
+		return completed + suffix, len(completed), true
+	}
+	fmt.Fprintf(terminal, "%s\n", strings.Join(matches, "  "))
+	return "", 0, false
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+func quoteWord(word string) string {
+	if !strings.ContainsAny(word, " \t'\"\\") {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// splitCommandLine splits a line into words the way a POSIX shell would for
+// plain quoting: single quotes are literal, double quotes allow backslash
+// escapes, and an unquoted backslash escapes the next character.
+func splitCommandLine(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// shellHistory keeps the most recent lines in memory and appends each new
+// line to ~/.gator_history so history survives between sessions.
+type shellHistory struct {
+	entries []string
+	path    string
+}
+
+func loadShellHistory() *shellHistory {
+	history := &shellHistory{}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return history
+	}
+	history.path = filepath.Join(homeDir, shellHistoryFileName)
+	data, err := os.ReadFile(history.path)
+	if err != nil {
+		return history
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			history.entries = append(history.entries, line)
+		}
+	}
+	if len(history.entries) > shellHistoryLimit {
+		history.entries = history.entries[len(history.entries)-shellHistoryLimit:]
+		os.WriteFile(history.path, []byte(strings.Join(history.entries, "\n")+"\n"), 0600)
+	}
+	return history
+}
+
+func (h *shellHistory) Add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > shellHistoryLimit {
+		h.entries = h.entries[1:]
+	}
+	if h.path == "" {
+		return
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, entry)
+}
+
+func (h *shellHistory) Len() int {
+	return len(h.entries)
+}
+
+func (h *shellHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
@@ -0,0 +1,12 @@
+-- name: GetCachedArticle :one
+SELECT * FROM article_cache
+WHERE url = $1 AND expires_at > $2;
+
+-- name: CacheArticle :exec
+INSERT INTO article_cache (url, content, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (url) DO UPDATE
+SET content = EXCLUDED.content, expires_at = EXCLUDED.expires_at;
+
+-- name: DeleteExpiredArticles :exec
+DELETE FROM article_cache WHERE expires_at <= $1;
\ No newline at end of file
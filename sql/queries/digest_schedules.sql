@@ -0,0 +1,31 @@
+-- name: SetDigestSchedule :one
+-- Changing an existing schedule keeps its last_sent_at.
+INSERT INTO digest_schedules (user_id, created_at, updated_at, email, frequency)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at, email = EXCLUDED.email, frequency = EXCLUDED.frequency
+RETURNING *;
+
+-- name: GetDigestSchedule :one
+SELECT * FROM digest_schedules
+WHERE user_id = $1;
+
+-- name: GetDigestSchedules :many
+SELECT digest_schedules.*, users.name AS user_name
+FROM digest_schedules
+INNER JOIN users ON digest_schedules.user_id = users.id
+ORDER BY users.name;
+
+-- name: MarkDigestAttempted :exec
+UPDATE digest_schedules
+SET last_attempt_at = $2
+WHERE user_id = $1;
+
+-- name: MarkDigestSent :exec
+UPDATE digest_schedules
+SET last_sent_at = $2
+WHERE user_id = $1;
+
+-- name: DeleteDigestSchedule :execrows
+DELETE FROM digest_schedules
+WHERE user_id = $1;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- name: CreateEnclosure :exec
+INSERT INTO enclosures (post_id, url, mime_type, length, duration_seconds)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: GetEnclosuresForPost :many
+SELECT * FROM enclosures
+WHERE post_id = $1
+ORDER BY id;
\ No newline at end of file
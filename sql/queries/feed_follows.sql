@@ -1,7 +1,7 @@
 -- name: CreateFeedFollow :one
 WITH inserted AS (
-INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO feed_follows (created_at, updated_at, user_id, feed_id)
+VALUES ($1, $2, $3, $4)
 RETURNING *)
 
 SELECT inserted.*, users.name AS user_name, feeds.name AS feed_name
@@ -10,11 +10,21 @@ INNER JOIN users ON inserted.user_id = users.id
 INNER JOIN feeds ON inserted.feed_id = feeds.id;
 
 -- name: GetFeedFollowsForUser :many
-SELECT feed_follows.*, feeds.name AS feed_name
+-- Follows come grouped by folder, unfiled feeds last.
+SELECT feed_follows.*, COALESCE(feed_follows.display_name, feeds.name) AS feed_name,
+    feeds.url AS feed_url,
+    folders.name AS folder_name
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
-WHERE feed_follows.user_id = $1;
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name IS NULL, folders.name, feed_name;
 
 -- name: DeleteFeedFollow :exec
 DELETE FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: SetFeedFollowDisplayName :execrows
+UPDATE feed_follows
+SET display_name = $3, updated_at = $4
 WHERE user_id = $1 AND feed_id = $2;
\ No newline at end of file
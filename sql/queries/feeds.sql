@@ -1,6 +1,6 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO feeds (created_at, updated_at, name, url, user_id)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetFeeds :many
@@ -20,4 +20,21 @@ WHERE id = $1;
 -- name: GetNextFeedToFetch :one
 SELECT * FROM feeds
 ORDER BY last_fetched_at ASC NULLS FIRST, updated_at ASC
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+
+-- name: GetFeedsByName :many
+SELECT * FROM feeds WHERE name = $1;
+
+
+-- name: GetAllFeeds :many
+SELECT * FROM feeds ORDER BY created_at;
+
+-- name: SetFeedRetention :exec
+UPDATE feeds
+SET retention_days = $2, retention_max_posts = $3, updated_at = $4
+WHERE id = $1;
+
+-- name: SetFeedFullText :exec
+UPDATE feeds
+SET full_text = $2, updated_at = $3
+WHERE id = $1;
\ No newline at end of file
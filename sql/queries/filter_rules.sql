@@ -0,0 +1,13 @@
+-- name: CreateFilterRule :one
+INSERT INTO filter_rules (created_at, user_id, action, kind, pattern, feed_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetFilterRulesForUser :many
+SELECT * FROM filter_rules
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: DeleteFilterRule :execrows
+DELETE FROM filter_rules
+WHERE id = $1 AND user_id = $2;
\ No newline at end of file
@@ -0,0 +1,38 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_states (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET read_at = COALESCE(post_states.read_at, EXCLUDED.read_at);
+
+-- name: SetPostStarred :exec
+INSERT INTO post_states (user_id, post_id, starred)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET starred = EXCLUDED.starred;
+
+-- name: GetPostsForUserFeed :many
+SELECT posts.*, post_states.read_at, COALESCE(post_states.starred, FALSE) AS starred
+FROM posts
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = $1
+WHERE posts.feed_id = $2
+ORDER BY posts.published_at DESC
+LIMIT $3;
+
+-- name: GetStarredPostsForUser :many
+SELECT posts.*, post_states.read_at, post_states.starred,
+    COALESCE(feed_follows.display_name, feeds.name) AS feed_name
+FROM posts
+INNER JOIN post_states ON post_states.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = post_states.user_id
+WHERE post_states.user_id = $1 AND post_states.starred
+ORDER BY posts.published_at DESC
+LIMIT $2;
+
+-- name: GetUnreadCountsForUser :many
+SELECT posts.feed_id, COUNT(*) AS unread
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = $1
+WHERE post_states.read_at IS NULL
+GROUP BY posts.feed_id;
\ No newline at end of file
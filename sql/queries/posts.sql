@@ -1,13 +1,77 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, published_at, title, url, description, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO posts (created_at, updated_at, published_at, title, url, description, feed_id, content, author)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: GetPostsForUser :many
-SELECT * FROM posts
-WHERE feed_id IN (
-    SELECT feed_id FROM feed_follows
-    WHERE user_id = $1
-)
-ORDER BY published_at DESC
-LIMIT $2;
\ No newline at end of file
+-- A folder_id of 0 and an empty tag or pattern match every post. pattern
+-- is a lowercase LIKE pattern matched against the title, description and
+-- content. created_after matches on when gator stored the post rather
+-- than when it was published. unread_only leaves out posts the user has
+-- read.
+SELECT posts.*, COALESCE(feed_follows.display_name, feeds.name) AS feed_name
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND (feed_follows.folder_id = sqlc.arg(folder_id) OR sqlc.arg(folder_id) = 0)
+AND (sqlc.arg(tag) = '' OR EXISTS (
+    SELECT 1 FROM post_tags
+    WHERE post_tags.post_id = posts.id
+    AND lower(post_tags.tag) = lower(sqlc.arg(tag))
+))
+AND (sqlc.arg(pattern) = ''
+    OR lower(posts.title) LIKE sqlc.arg(pattern) ESCAPE '\'
+    OR lower(posts.description) LIKE sqlc.arg(pattern) ESCAPE '\'
+    OR lower(posts.content) LIKE sqlc.arg(pattern) ESCAPE '\')
+AND posts.published_at >= sqlc.arg(published_after)
+AND posts.created_at >= sqlc.arg(created_after)
+AND (NOT sqlc.arg(unread_only) OR NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+    AND post_states.user_id = sqlc.arg(user_id)
+    AND post_states.read_at IS NOT NULL
+))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: GetPostByID :one
+SELECT posts.*, feeds.name AS feed_name
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE posts.id = $1;
+
+-- name: PostExists :one
+SELECT EXISTS (SELECT 1 FROM posts WHERE url = $1);
+
+-- name: PrunePosts :execrows
+-- Deletes a feed's posts that are older than published_before or ranked
+-- past max_posts (0 for no limit), except starred posts and posts newer
+-- than unread_since that a follower has not read yet. A post's age is the
+-- later of its publication and when it was stored, so a post with a
+-- missing or bogus date is not deleted as soon as it is fetched.
+DELETE FROM posts
+WHERE posts.id IN (
+    SELECT ranked.id FROM (
+        SELECT id, GREATEST(published_at, created_at) AS dated_at,
+            ROW_NUMBER() OVER (ORDER BY published_at DESC, id DESC) AS position
+        FROM posts
+        WHERE feed_id = sqlc.arg(feed_id)
+    ) AS ranked
+    WHERE (ranked.dated_at < sqlc.arg(published_before)
+        OR (sqlc.arg(max_posts)::bigint > 0 AND ranked.position > sqlc.arg(max_posts)::bigint))
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = ranked.id AND post_states.starred
+    )
+    AND NOT (ranked.dated_at >= sqlc.arg(unread_since) AND EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = sqlc.arg(feed_id)
+        AND NOT EXISTS (
+            SELECT 1 FROM post_states
+            WHERE post_states.post_id = ranked.id
+            AND post_states.user_id = feed_follows.user_id
+            AND post_states.read_at IS NOT NULL
+        )
+    ))
+);
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- name: SaveSearch :one
+-- Saving under an existing name replaces that search.
+INSERT INTO saved_searches (created_at, updated_at, user_id, name, query, tag, folder_id, within_days)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (user_id, name) DO UPDATE
+SET updated_at = EXCLUDED.updated_at, query = EXCLUDED.query, tag = EXCLUDED.tag,
+    folder_id = EXCLUDED.folder_id, within_days = EXCLUDED.within_days
+RETURNING *;
+
+-- name: GetSavedSearchByName :one
+SELECT * FROM saved_searches
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetSavedSearchesForUser :many
+SELECT saved_searches.*, folders.name AS folder_name
+FROM saved_searches
+LEFT JOIN folders ON saved_searches.folder_id = folders.id
+WHERE saved_searches.user_id = $1
+ORDER BY saved_searches.name;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches
+WHERE user_id = $1 AND name = $2;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- name: AddPostTag :exec
+INSERT INTO post_tags (post_id, tag)
+VALUES ($1, $2)
+ON CONFLICT (post_id, tag) DO NOTHING;
+
+-- name: GetTagsForPost :many
+SELECT tag FROM post_tags
+WHERE post_id = $1
+ORDER BY lower(tag);
+
+-- name: GetTopTags :many
+-- Counts tags on one feed's posts, or on all followed feeds when feed_id
+-- is 0.
+SELECT post_tags.tag, COUNT(*) AS post_count
+FROM post_tags
+INNER JOIN posts ON post_tags.post_id = posts.id
+WHERE posts.feed_id = sqlc.arg(feed_id)
+OR (sqlc.arg(feed_id) = 0 AND posts.feed_id IN (
+    SELECT feed_id FROM feed_follows
+    WHERE user_id = sqlc.arg(user_id)
+))
+GROUP BY post_tags.tag
+ORDER BY post_count DESC, post_tags.tag
+LIMIT sqlc.arg(row_limit);
\ No newline at end of file
@@ -0,0 +1,56 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (created_at, user_id, feed_id, url, secret)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetWebhooksForUser :many
+SELECT webhooks.*, feeds.name AS feed_name
+FROM webhooks
+LEFT JOIN feeds ON webhooks.feed_id = feeds.id
+WHERE webhooks.user_id = $1
+ORDER BY webhooks.id;
+
+-- name: GetWebhooksForFeed :many
+-- Webhooks on the feed itself and per-user webhooks, of everyone who
+-- follows it; a webhook stops firing when its owner unfollows the feed.
+-- feed_name is the feed as the webhook's owner sees it.
+SELECT webhooks.*, COALESCE(feed_follows.display_name, feeds.name) AS feed_name
+FROM webhooks
+INNER JOIN feeds ON feeds.id = sqlc.arg(feed_id)
+INNER JOIN feed_follows ON feed_follows.feed_id = feeds.id
+    AND feed_follows.user_id = webhooks.user_id
+WHERE webhooks.feed_id = feeds.id OR webhooks.feed_id IS NULL
+ORDER BY webhooks.id;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (created_at, updated_at, webhook_id, post_id, payload, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetDueWebhookDeliveries :many
+SELECT webhook_deliveries.*, webhooks.url, webhooks.secret
+FROM webhook_deliveries
+INNER JOIN webhooks ON webhook_deliveries.webhook_id = webhooks.id
+WHERE webhook_deliveries.status = 'pending'
+AND webhook_deliveries.next_attempt_at <= sqlc.arg(now)
+ORDER BY webhook_deliveries.next_attempt_at, webhook_deliveries.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET updated_at = $2, status = $3, attempts = $4, next_attempt_at = $5, response_code = $6, last_error = $7
+WHERE id = $1;
+
+-- name: GetWebhookDeliveriesForUser :many
+-- The newest deliveries first. webhook_id 0 lists every webhook's.
+SELECT webhook_deliveries.*, webhooks.url, posts.title AS post_title
+FROM webhook_deliveries
+INNER JOIN webhooks ON webhook_deliveries.webhook_id = webhooks.id
+LEFT JOIN posts ON webhook_deliveries.post_id = posts.id
+WHERE webhooks.user_id = sqlc.arg(user_id)
+AND (webhooks.id = sqlc.arg(webhook_id) OR sqlc.arg(webhook_id) = 0)
+ORDER BY webhook_deliveries.id DESC
+LIMIT sqlc.arg(row_limit);
\ No newline at end of file
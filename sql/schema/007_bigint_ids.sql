@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE users ALTER COLUMN id TYPE BIGINT;
+ALTER TABLE feeds ALTER COLUMN id TYPE BIGINT, ALTER COLUMN user_id TYPE BIGINT;
+ALTER TABLE feed_follows ALTER COLUMN id TYPE BIGINT, ALTER COLUMN user_id TYPE BIGINT, ALTER COLUMN feed_id TYPE BIGINT;
+ALTER TABLE posts ALTER COLUMN id TYPE BIGINT, ALTER COLUMN feed_id TYPE BIGINT;
+ALTER TABLE post_states ALTER COLUMN user_id TYPE BIGINT, ALTER COLUMN post_id TYPE BIGINT;
+
+ALTER TABLE users ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+ALTER TABLE feeds ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+ALTER TABLE feed_follows ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+ALTER TABLE posts ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+
+-- Existing rows carry random truncated-UUID ids, so start each sequence
+-- past the largest one.
+SELECT setval(pg_get_serial_sequence('users', 'id'), GREATEST(MAX(id), 0) + 1, false) FROM users;
+SELECT setval(pg_get_serial_sequence('feeds', 'id'), GREATEST(MAX(id), 0) + 1, false) FROM feeds;
+SELECT setval(pg_get_serial_sequence('feed_follows', 'id'), GREATEST(MAX(id), 0) + 1, false) FROM feed_follows;
+SELECT setval(pg_get_serial_sequence('posts', 'id'), GREATEST(MAX(id), 0) + 1, false) FROM posts;
+
+-- +goose Down
+ALTER TABLE posts ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE feed_follows ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE feeds ALTER COLUMN id DROP IDENTITY;
+ALTER TABLE users ALTER COLUMN id DROP IDENTITY;
+
+ALTER TABLE post_states ALTER COLUMN user_id TYPE INTEGER, ALTER COLUMN post_id TYPE INTEGER;
+ALTER TABLE posts ALTER COLUMN id TYPE INTEGER, ALTER COLUMN feed_id TYPE INTEGER;
+ALTER TABLE feed_follows ALTER COLUMN id TYPE INTEGER, ALTER COLUMN user_id TYPE INTEGER, ALTER COLUMN feed_id TYPE INTEGER;
+ALTER TABLE feeds ALTER COLUMN id TYPE INTEGER, ALTER COLUMN user_id TYPE INTEGER;
+ALTER TABLE users ALTER COLUMN id TYPE INTEGER;
\ No newline at end of file
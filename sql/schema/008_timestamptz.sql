@@ -0,0 +1,37 @@
+-- +goose Up
+-- Existing values are read as wall-clock times in the session's TimeZone;
+-- run SET TIME ZONE first if gator was writing from a different zone.
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+ALTER TABLE feeds
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN last_fetched_at TYPE TIMESTAMPTZ;
+ALTER TABLE feed_follows
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+ALTER TABLE posts
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN published_at TYPE TIMESTAMPTZ;
+ALTER TABLE post_states
+    ALTER COLUMN read_at TYPE TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE post_states
+    ALTER COLUMN read_at TYPE TIMESTAMP;
+ALTER TABLE posts
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP,
+    ALTER COLUMN published_at TYPE TIMESTAMP;
+ALTER TABLE feed_follows
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+ALTER TABLE feeds
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP,
+    ALTER COLUMN last_fetched_at TYPE TIMESTAMP;
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
\ No newline at end of file
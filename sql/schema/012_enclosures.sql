@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE enclosures (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    post_id BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    mime_type TEXT NOT NULL DEFAULT '',
+    length BIGINT NOT NULL DEFAULT 0,
+    duration_seconds INTEGER,
+    FOREIGN KEY(post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    UNIQUE (post_id, url)
+);
+
+-- +goose Down
+DROP TABLE enclosures;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE post_tags (
+    post_id BIGINT NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (post_id, tag),
+    FOREIGN KEY(post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX post_tags_lower_tag_idx ON post_tags (lower(tag));
+
+-- +goose Down
+DROP TABLE post_tags;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE folders (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users (id) ON DELETE CASCADE,
+    UNIQUE (user_id, name)
+);
+
+ALTER TABLE feed_follows
+ADD COLUMN folder_id BIGINT REFERENCES folders (id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN folder_id;
+
+DROP TABLE folders;
\ No newline at end of file
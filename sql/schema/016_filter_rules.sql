@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN author TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE filter_rules (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    user_id BIGINT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('include', 'exclude', 'highlight')),
+    kind TEXT NOT NULL CHECK (kind IN ('keyword', 'regex', 'feed', 'author')),
+    pattern TEXT NOT NULL,
+    feed_id BIGINT,
+    FOREIGN KEY(user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY(feed_id) REFERENCES feeds (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE filter_rules;
+
+ALTER TABLE posts
+DROP COLUMN author;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE saved_searches (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    tag TEXT NOT NULL DEFAULT '',
+    folder_id BIGINT,
+    within_days INTEGER,
+    FOREIGN KEY(user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY(folder_id) REFERENCES folders (id),
+    UNIQUE (user_id, name)
+);
+
+-- +goose Down
+DROP TABLE saved_searches;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE digest_schedules (
+    user_id BIGINT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    email TEXT NOT NULL,
+    frequency TEXT NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+    last_sent_at TIMESTAMPTZ,
+    last_attempt_at TIMESTAMPTZ,
+    FOREIGN KEY(user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE digest_schedules;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: CreateFeedFollow :one
+INSERT INTO feed_follows (created_at, updated_at, user_id, feed_id)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id, display_name,
+    (SELECT name FROM users WHERE users.id = feed_follows.user_id) AS user_name,
+    (SELECT name FROM feeds WHERE feeds.id = feed_follows.feed_id) AS feed_name;
\ No newline at end of file
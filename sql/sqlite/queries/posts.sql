@@ -0,0 +1,26 @@
+-- name: PrunePosts :execrows
+DELETE FROM posts
+WHERE posts.id IN (
+    SELECT ranked.id FROM (
+        SELECT id, MAX(published_at, created_at) AS dated_at,
+            ROW_NUMBER() OVER (ORDER BY published_at DESC, id DESC) AS position
+        FROM posts
+        WHERE feed_id = $1
+    ) AS ranked
+    WHERE (ranked.dated_at < $2
+        OR ($3 > 0 AND ranked.position > $3))
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states
+        WHERE post_states.post_id = ranked.id AND post_states.starred
+    )
+    AND NOT (ranked.dated_at >= $4 AND EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = $1
+        AND NOT EXISTS (
+            SELECT 1 FROM post_states
+            WHERE post_states.post_id = ranked.id
+            AND post_states.user_id = feed_follows.user_id
+            AND post_states.read_at IS NOT NULL
+        )
+    ))
+);
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT UNIQUE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE users;
\ No newline at end of file
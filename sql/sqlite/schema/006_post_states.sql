@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE post_states (
+    user_id INTEGER NOT NULL,
+    post_id INTEGER NOT NULL,
+    read_at TIMESTAMP,
+    starred BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (user_id, post_id),
+    FOREIGN KEY(user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY(post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE post_states;
\ No newline at end of file
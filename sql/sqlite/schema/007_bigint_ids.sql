@@ -0,0 +1,6 @@
+-- +goose Up
+-- SQLite INTEGER PRIMARY KEY columns are already 64-bit rowid aliases that
+-- are assigned automatically when the id is omitted, so only the Postgres
+-- schema needs changing.
+
+-- +goose Down
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- +goose Up
+-- SQLite keeps the offset with each value but compares them as text, so
+-- rewrite everything in UTC to make ORDER BY agree with time order.
+UPDATE users SET
+    created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at);
+UPDATE feeds SET
+    created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at),
+    last_fetched_at = strftime('%Y-%m-%d %H:%M:%f+00:00', last_fetched_at);
+UPDATE feed_follows SET
+    created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at);
+UPDATE posts SET
+    created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at),
+    published_at = strftime('%Y-%m-%d %H:%M:%f+00:00', published_at);
+UPDATE post_states SET
+    read_at = strftime('%Y-%m-%d %H:%M:%f+00:00', read_at);
+
+-- +goose Down
+-- UTC values remain valid, so there is nothing to undo.
\ No newline at end of file
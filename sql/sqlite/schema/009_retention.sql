@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN retention_days INTEGER;
+ALTER TABLE feeds ADD COLUMN retention_max_posts INTEGER;
+
+CREATE INDEX posts_feed_id_published_at_idx ON posts (feed_id, published_at DESC);
+
+-- +goose Down
+DROP INDEX posts_feed_id_published_at_idx;
+
+ALTER TABLE feeds DROP COLUMN retention_days;
+ALTER TABLE feeds DROP COLUMN retention_max_posts;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN full_text BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE article_cache (
+    url TEXT PRIMARY KEY,
+    content TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE article_cache;
+
+ALTER TABLE feeds
+DROP COLUMN full_text;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feed_follows
+ADD COLUMN display_name TEXT;
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN display_name;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE digest_schedules (
+    user_id INTEGER PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    email TEXT NOT NULL,
+    frequency TEXT NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+    last_sent_at TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE digest_schedules;
\ No newline at end of file
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+
+	memstore "github.com/louiehdev/gatorcli/internal/memstore"
+
+	migrate "github.com/louiehdev/gatorcli/internal/migrate"
+
+	sqlite "github.com/louiehdev/gatorcli/internal/sqlite"
+)
+
+// txFunc runs fn with queries that share one transaction, committing when
+// fn returns nil and rolling back otherwise.
+type txFunc func(ctx context.Context, fn func(database.Querier) error) error
+
+// openDatabase picks the storage backend from the db_url scheme: sqlite:
+// URLs use an SQLite file, anything else is handed to the Postgres driver.
+func openDatabase(url string) (*sql.DB, database.Querier, txFunc, *migrate.Migrator, error) {
+	if sqlite.IsURL(url) {
+		db, err := sqlite.Open(url)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		wrapped, err := sqlite.Wrap(db, embeddedDir("sql/sqlite/queries"))
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		migrator, err := migrate.New(db, embeddedDir("sql/sqlite/schema"), migrate.SQLite)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		inTx := transactions(db, func(tx *sql.Tx) database.Querier {
+			return database.New(wrapped.WithTx(tx))
+		})
+		return db, database.New(wrapped), inTx, migrator, nil
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	migrator, err := migrate.New(db, embeddedDir("sql/schema"), migrate.Postgres)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	queries := database.New(db)
+	inTx := transactions(db, func(tx *sql.Tx) database.Querier {
+		return queries.WithTx(tx)
+	})
+	return db, queries, inTx, migrator, nil
+}
+
+// transactions returns a txFunc that begins each transaction on db and
+// binds queries to it with bind.
+func transactions(db *sql.DB, bind func(tx *sql.Tx) database.Querier) txFunc {
+	return func(ctx context.Context, fn func(database.Querier) error) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := fn(bind(tx)); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	return sqlite.IsUniqueViolation(err) || errors.Is(err, memstore.ErrUniqueViolation)
+}
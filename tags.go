@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+type tagRecord struct {
+	Tag   string `json:"tag"`
+	Posts int64  `json:"posts"`
+}
+
+// commandTags lists the most common tags on a feed's posts, or across the
+// user's followed feeds when no feed is given.
+func commandTags(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	params := database.GetTopTagsParams{UserID: user.ID, RowLimit: int32(cmd.intFlag("limit"))}
+	if len(cmd.arguments) == 1 {
+		feed, err := resolveUserFeed(ctx, s, user, cmd.arguments[0])
+		if err != nil {
+			return err
+		}
+		params.FeedID = feed.ID
+	}
+	tags, err := s.db.GetTopTags(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	records := make([]tagRecord, 0, len(tags))
+	rows := make([][]string, 0, len(tags))
+	for _, tag := range tags {
+		records = append(records, tagRecord{Tag: tag.Tag, Posts: tag.PostCount})
+		rows = append(rows, []string{tag.Tag, strconv.FormatInt(tag.PostCount, 10)})
+	}
+	return s.render(listing{
+		columns: []string{"tag", "posts"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			if len(tags) == 0 {
+				fmt.Fprintln(w, "No tags yet")
+				return
+			}
+			for _, tag := range tags {
+				fmt.Fprintf(w, "%5d  %s\n", tag.PostCount, tag.Tag)
+			}
+		},
+	})
+}
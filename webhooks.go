@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+const (
+	webhookPending   = "pending"
+	webhookDelivered = "delivered"
+	webhookFailed    = "failed"
+
+	// A delivery is tried webhookAttempts times, waiting webhookRetryDelay
+	// after the first failure and twice as long after each one after it.
+	webhookAttempts   = 5
+	webhookRetryDelay = time.Minute
+	webhookTimeout    = 10 * time.Second
+	webhookBatchSize  = 50
+
+	// Up to webhookWorkers deliveries are sent at once, and each agg tick
+	// spends at most webhookTickBudget sending; whatever is left waits for
+	// the next tick.
+	webhookWorkers    = 8
+	webhookTickBudget = 30 * time.Second
+)
+
+// webhookPayload is the JSON body POSTed for each new post.
+type webhookPayload struct {
+	Event string      `json:"event"`
+	Feed  webhookFeed `json:"feed"`
+	Post  postRecord  `json:"post"`
+}
+
+type webhookFeed struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+type webhookRecord struct {
+	ID        int64  `json:"id"`
+	Url       string `json:"url"`
+	FeedName  string `json:"feed_name,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type webhookDeliveryRecord struct {
+	ID           int64  `json:"id"`
+	WebhookID    int64  `json:"webhook_id"`
+	Url          string `json:"url"`
+	PostID       int64  `json:"post_id"`
+	PostTitle    string `json:"post_title,omitempty"`
+	Status       string `json:"status"`
+	Attempts     int32  `json:"attempts"`
+	ResponseCode int32  `json:"response_code,omitempty"`
+	LastError    string `json:"last_error,omitempty"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+// commandWebhook manages the current user's webhooks:
+//
+//	webhook add <url> [feed] [--secret s]
+//	webhook delete <id>
+//	webhook list
+//	webhook log [id] [--limit n]
+//
+// A webhook without a feed fires for new posts on every feed the user
+// follows.
+func commandWebhook(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	action, args := cmd.arguments[0], cmd.arguments[1:]
+	switch action {
+	case "add":
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("usage: webhook add <url> [feed_url|feed_name] [--secret s]")
+		}
+		return addWebhook(ctx, s, cmd, user, args)
+	case "delete":
+		if len(args) != 1 {
+			return errors.New("usage: webhook delete <id>")
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid webhook id %q", args[0])
+		}
+		deleted, err := s.db.DeleteWebhook(ctx, database.DeleteWebhookParams{ID: id, UserID: user.ID})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return fmt.Errorf("no webhook with id %d", id)
+		}
+		fmt.Printf("Deleted webhook %d\n", id)
+		return nil
+	case "list":
+		if len(args) != 0 {
+			return errors.New("usage: webhook list")
+		}
+		return listWebhooks(ctx, s, user)
+	case "log":
+		if len(args) > 1 {
+			return errors.New("usage: webhook log [id] [--limit n]")
+		}
+		var id int64
+		if len(args) == 1 {
+			var err error
+			if id, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+				return fmt.Errorf("invalid webhook id %q", args[0])
+			}
+		}
+		return listWebhookDeliveries(ctx, s, user, id, int32(cmd.intFlag("limit")))
+	default:
+		return fmt.Errorf("unknown webhook action %q (expected %s)", action, strings.Join(webhookActions, ", "))
+	}
+}
+
+func addWebhook(ctx context.Context, s *state, cmd command, user database.User, args []string) error {
+	target, err := url.Parse(args[0])
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q (expected http or https)", args[0])
+	}
+	var feedID sql.NullInt64
+	scope := "all followed feeds"
+	if len(args) == 2 {
+		feed, err := resolveUserFeed(ctx, s, user, args[1])
+		if err != nil {
+			return err
+		}
+		feedID = sql.NullInt64{Int64: feed.ID, Valid: true}
+		scope = feed.Name
+	}
+	secret := cmd.stringFlag("secret")
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		secret = hex.EncodeToString(buf)
+	}
+	webhook, err := s.db.CreateWebhook(ctx, database.CreateWebhookParams{
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feedID,
+		Url:       target.String(),
+		Secret:    secret,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added webhook %d for %s\n", webhook.ID, scope)
+	fmt.Printf("Signing secret: %s\n", secret)
+	return nil
+}
+
+func listWebhooks(ctx context.Context, s *state, user database.User) error {
+	webhooks, err := s.db.GetWebhooksForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	records := make([]webhookRecord, 0, len(webhooks))
+	rows := make([][]string, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		createdAt := s.localTime(webhook.CreatedAt).Format(time.RFC3339)
+		records = append(records, webhookRecord{ID: webhook.ID, Url: webhook.Url, FeedName: webhook.FeedName.String, CreatedAt: createdAt})
+		rows = append(rows, []string{strconv.FormatInt(webhook.ID, 10), webhook.Url, webhook.FeedName.String, createdAt})
+	}
+	return s.render(listing{
+		columns: []string{"id", "url", "feed_name", "created_at"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			if len(webhooks) == 0 {
+				fmt.Fprintln(w, "No webhooks")
+				return
+			}
+			for _, webhook := range webhooks {
+				scope := "all followed feeds"
+				if webhook.FeedName.Valid {
+					scope = webhook.FeedName.String
+				}
+				fmt.Fprintf(w, "%3d  %s (%s)\n", webhook.ID, webhook.Url, scope)
+			}
+		},
+	})
+}
+
+func listWebhookDeliveries(ctx context.Context, s *state, user database.User, webhookID int64, limit int32) error {
+	deliveries, err := s.db.GetWebhookDeliveriesForUser(ctx, database.GetWebhookDeliveriesForUserParams{
+		UserID:    user.ID,
+		WebhookID: webhookID,
+		RowLimit:  limit,
+	})
+	if err != nil {
+		return err
+	}
+	records := make([]webhookDeliveryRecord, 0, len(deliveries))
+	rows := make([][]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		updatedAt := s.localTime(delivery.UpdatedAt).Format(time.RFC3339)
+		records = append(records, webhookDeliveryRecord{
+			ID:           delivery.ID,
+			WebhookID:    delivery.WebhookID,
+			Url:          delivery.Url,
+			PostID:       delivery.PostID,
+			PostTitle:    delivery.PostTitle.String,
+			Status:       delivery.Status,
+			Attempts:     delivery.Attempts,
+			ResponseCode: delivery.ResponseCode.Int32,
+			LastError:    delivery.LastError,
+			UpdatedAt:    updatedAt,
+		})
+		responseCode := ""
+		if delivery.ResponseCode.Valid {
+			responseCode = strconv.Itoa(int(delivery.ResponseCode.Int32))
+		}
+		rows = append(rows, []string{
+			strconv.FormatInt(delivery.ID, 10),
+			strconv.FormatInt(delivery.WebhookID, 10),
+			delivery.Url,
+			strconv.FormatInt(delivery.PostID, 10),
+			delivery.Status,
+			strconv.Itoa(int(delivery.Attempts)),
+			responseCode,
+			delivery.LastError,
+			updatedAt,
+		})
+	}
+	return s.render(listing{
+		columns: []string{"id", "webhook_id", "url", "post_id", "status", "attempts", "response_code", "last_error", "updated_at"},
+		rows:    rows,
+		records: records,
+		plain: func(w io.Writer) {
+			if len(deliveries) == 0 {
+				fmt.Fprintln(w, "No webhook deliveries")
+				return
+			}
+			for _, delivery := range deliveries {
+				title := delivery.PostTitle.String
+				if !delivery.PostTitle.Valid {
+					title = fmt.Sprintf("post %d", delivery.PostID)
+				}
+				fmt.Fprintf(w, "%s  %-9s  #%d %s -> %s", relativeTime(delivery.UpdatedAt, time.Now()), delivery.Status, delivery.WebhookID, title, delivery.Url)
+				if delivery.Attempts > 1 {
+					fmt.Fprintf(w, " (%s)", pluralize(int(delivery.Attempts), "attempt"))
+				}
+				fmt.Fprintln(w)
+				if delivery.LastError != "" && delivery.Status != webhookDelivered {
+					fmt.Fprintf(w, "    %s\n", delivery.LastError)
+				}
+			}
+		},
+	})
+}
+
+// queueWebhooks records a pending delivery of post to each of webhooks,
+// naming the feed as each webhook's owner sees it. The deliveries are sent
+// afterwards by deliverWebhooks, so storing posts does not wait on the
+// endpoints.
+func queueWebhooks(ctx context.Context, s *state, webhooks []database.GetWebhooksForFeedRow, feed database.Feed, post database.Post, tags []string, enclosures []enclosure) error {
+	if len(webhooks) == 0 {
+		return nil
+	}
+	payload := webhookPayload{
+		Event: "post.created",
+		Feed:  webhookFeed{ID: feed.ID, Url: feed.Url},
+		Post: postRecord{
+			ID:          post.ID,
+			Title:       post.Title,
+			Url:         post.Url,
+			PublishedAt: post.PublishedAt.UTC().Format(time.RFC3339),
+			Description: post.Description,
+			Content:     post.Content,
+			Author:      post.Author,
+			Tags:        tags,
+		},
+	}
+	for _, enc := range enclosures {
+		payload.Post.Enclosures = append(payload.Post.Enclosures, enclosureRecord{
+			Url:             enc.URL,
+			MimeType:        enc.MimeType,
+			Length:          enc.Length,
+			DurationSeconds: int32(enc.Duration),
+		})
+	}
+	now := time.Now().UTC()
+	for _, webhook := range webhooks {
+		payload.Feed.Name, payload.Post.FeedName = webhook.FeedName, webhook.FeedName
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if err := s.db.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			WebhookID:     webhook.ID,
+			PostID:        post.ID,
+			Payload:       string(body),
+			NextAttemptAt: now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverWebhooks sends the deliveries that are due, several at a time and
+// within webhookTickBudget, and records how each went. Failed deliveries
+// are retried with a growing delay until they run out of attempts.
+func deliverWebhooks(ctx context.Context, s *state) error {
+	now := time.Now().UTC()
+	due, err := s.db.GetDueWebhookDeliveries(ctx, database.GetDueWebhookDeliveriesParams{Now: now, RowLimit: webhookBatchSize})
+	if err != nil {
+		return err
+	}
+
+	type result struct {
+		sent bool
+		code int
+		err  error
+	}
+	results := make([]result, len(due))
+	sendCtx, cancel := context.WithTimeout(ctx, webhookTickBudget)
+	defer cancel()
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(webhookWorkers, len(due)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				delivery := due[idx]
+				code, err := sendWebhook(sendCtx, delivery.Url, delivery.Secret, delivery.ID, []byte(delivery.Payload))
+				if err != nil && sendCtx.Err() != nil {
+					// Cut short by the tick budget rather than the
+					// endpoint: the delivery stays due for the next tick
+					// without using up an attempt.
+					continue
+				}
+				results[idx] = result{sent: true, code: code, err: err}
+			}
+		}()
+	}
+	for idx := range due {
+		if sendCtx.Err() != nil {
+			break
+		}
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	for idx, delivery := range due {
+		result := results[idx]
+		if !result.sent {
+			continue
+		}
+		update := database.UpdateWebhookDeliveryParams{
+			ID:            delivery.ID,
+			UpdatedAt:     time.Now().UTC(),
+			Status:        webhookDelivered,
+			Attempts:      delivery.Attempts + 1,
+			NextAttemptAt: delivery.NextAttemptAt,
+			ResponseCode:  sql.NullInt32{Int32: int32(result.code), Valid: result.code != 0},
+		}
+		if result.err != nil {
+			update.LastError = result.err.Error()
+			if update.Attempts >= webhookAttempts {
+				update.Status = webhookFailed
+				fmt.Printf("Webhook delivery %d to %s failed, giving up: %v\n", delivery.ID, delivery.Url, result.err)
+			} else {
+				update.Status = webhookPending
+				update.NextAttemptAt = update.UpdatedAt.Add(webhookRetryDelay << (update.Attempts - 1))
+				fmt.Printf("Webhook delivery %d to %s failed, retrying at %s: %v\n",
+					delivery.ID, delivery.Url, s.localTime(update.NextAttemptAt).Format(time.Kitchen), result.err)
+			}
+		}
+		if err := s.db.UpdateWebhookDelivery(ctx, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendWebhook POSTs body to target, signed with an HMAC-SHA256 of the body
+// keyed by secret in the X-Gator-Signature header. Any 2xx response
+// counts as delivered; the status code is returned whenever there was a
+// response.
+func sendWebhook(ctx context.Context, target, secret string, deliveryID int64, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("X-Gator-Event", "post.created")
+	req.Header.Set("X-Gator-Delivery", strconv.FormatInt(deliveryID, 10))
+	req.Header.Set("X-Gator-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	database "github.com/louiehdev/gatorcli/internal/database"
+)
+
+// createTestWebhook adds a webhook for user, on feed or on every feed the
+// user follows when feed is nil.
+func createTestWebhook(t *testing.T, s *state, user database.User, feed *database.Feed, url string) database.Webhook {
+	t.Helper()
+	var feedID sql.NullInt64
+	if feed != nil {
+		feedID = sql.NullInt64{Int64: feed.ID, Valid: true}
+	}
+	webhook, err := s.db.CreateWebhook(context.Background(), database.CreateWebhookParams{
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feedID,
+		Url:       url,
+		Secret:    "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return webhook
+}
+
+func TestQueueWebhooksUsesOwnersFeedName(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	user, feed := createTestFeed(t, s, "ann")
+	if _, err := s.repo.SetFeedFollowDisplayName(ctx, database.SetFeedFollowDisplayNameParams{
+		UserID:      user.ID,
+		FeedID:      feed.ID,
+		DisplayName: sql.NullString{String: "Mine", Valid: true},
+		UpdatedAt:   time.Now().UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	createTestWebhook(t, s, user, nil, "https://example.com/hook")
+	post := createTestPosts(t, s, feed, "Hello")[0]
+
+	webhooks, err := s.db.GetWebhooksForFeed(ctx, feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queueWebhooks(ctx, s, webhooks, feed, post, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	due, err := s.db.GetDueWebhookDeliveries(ctx, database.GetDueWebhookDeliveriesParams{Now: time.Now().UTC(), RowLimit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("queued %d deliveries, want 1", len(due))
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal([]byte(due[0].Payload), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Feed.Name != "Mine" || payload.Post.FeedName != "Mine" {
+		t.Errorf("payload names the feed %q and %q, want Mine", payload.Feed.Name, payload.Post.FeedName)
+	}
+}
+
+func TestGetWebhooksForFeedNeedsOwnerToFollow(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	ann, feed := createTestFeed(t, s, "ann")
+	bob, _ := createTestFeed(t, s, "bob")
+	annAll := createTestWebhook(t, s, ann, nil, "https://example.com/ann")
+	bobAll := createTestWebhook(t, s, bob, nil, "https://example.com/bob-all")
+	bobFeed := createTestWebhook(t, s, bob, &feed, "https://example.com/bob-feed")
+
+	webhookIDs := func() []int64 {
+		t.Helper()
+		webhooks, err := s.db.GetWebhooksForFeed(ctx, feed.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		for _, webhook := range webhooks {
+			ids = append(ids, webhook.ID)
+		}
+		return ids
+	}
+	if got, want := webhookIDs(), []int64{annAll.ID}; !slices.Equal(got, want) {
+		t.Errorf("webhooks for ann's feed before bob follows it: %v, want %v", got, want)
+	}
+	now := time.Now().UTC()
+	if _, err := s.repo.CreateFeedFollow(ctx, database.CreateFeedFollowParams{CreatedAt: now, UpdatedAt: now, UserID: bob.ID, FeedID: feed.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := webhookIDs(), []int64{annAll.ID, bobAll.ID, bobFeed.ID}; !slices.Equal(got, want) {
+		t.Errorf("webhooks for ann's feed after bob follows it: %v, want %v", got, want)
+	}
+}
+
+func TestSendWebhookSignsBody(t *testing.T) {
+	var signature, delivery string
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature, delivery = r.Header.Get("X-Gator-Signature"), r.Header.Get("X-Gator-Delivery")
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	body := []byte(`{"event":"post.created"}`)
+	code, err := sendWebhook(context.Background(), server.URL, "s3cret", 42, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != http.StatusNoContent {
+		t.Errorf("got status %d, want %d", code, http.StatusNoContent)
+	}
+	if string(received) != string(body) {
+		t.Errorf("server received %q, want %q", received, body)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(received)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("X-Gator-Signature is %q, want %q", signature, want)
+	}
+	if delivery != "42" {
+		t.Errorf("X-Gator-Delivery is %q, want 42", delivery)
+	}
+}
+
+func TestDeliverWebhooksRetriesThenGivesUp(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer server.Close()
+	user, feed := createTestFeed(t, s, "ann")
+	webhook := createTestWebhook(t, s, user, nil, server.URL)
+	queueTestDelivery(t, s, feed)
+
+	for attempt := int32(1); attempt <= webhookAttempts; attempt++ {
+		if err := deliverWebhooks(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+		delivery := lastTestDelivery(t, s, user, webhook)
+		if delivery.Attempts != attempt {
+			t.Fatalf("delivery has %d attempts after try %d", delivery.Attempts, attempt)
+		}
+		if !delivery.ResponseCode.Valid || delivery.ResponseCode.Int32 != http.StatusBadGateway || delivery.LastError == "" {
+			t.Errorf("try %d: response code %v and error %q not recorded", attempt, delivery.ResponseCode, delivery.LastError)
+		}
+		if attempt == webhookAttempts {
+			if delivery.Status != webhookFailed {
+				t.Errorf("delivery is %s after %d tries, want %s", delivery.Status, attempt, webhookFailed)
+			}
+			break
+		}
+		if delivery.Status != webhookPending {
+			t.Fatalf("delivery is %s after try %d, want %s", delivery.Status, attempt, webhookPending)
+		}
+		if got, want := delivery.NextAttemptAt.Sub(delivery.UpdatedAt), webhookRetryDelay<<(attempt-1); got != want {
+			t.Errorf("try %d: retry in %v, want %v", attempt, got, want)
+		}
+		// Not due yet, so the next tick leaves it alone.
+		if err := deliverWebhooks(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+		if requests.Load() != attempt {
+			t.Fatalf("sent %d requests after try %d, before the retry was due", requests.Load(), attempt)
+		}
+		// Move the retry to now rather than waiting for it.
+		if err := s.db.UpdateWebhookDelivery(ctx, database.UpdateWebhookDeliveryParams{
+			ID:            delivery.ID,
+			UpdatedAt:     delivery.UpdatedAt,
+			Status:        delivery.Status,
+			Attempts:      delivery.Attempts,
+			NextAttemptAt: time.Now().UTC(),
+			ResponseCode:  delivery.ResponseCode,
+			LastError:     delivery.LastError,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := deliverWebhooks(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != webhookAttempts {
+		t.Errorf("sent %d requests, want %d", requests.Load(), webhookAttempts)
+	}
+}
+
+func TestDeliverWebhooksMarksDelivered(t *testing.T) {
+	ctx := context.Background()
+	s := newTestState(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	user, feed := createTestFeed(t, s, "ann")
+	webhook := createTestWebhook(t, s, user, nil, server.URL)
+	queueTestDelivery(t, s, feed)
+
+	if err := deliverWebhooks(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	delivery := lastTestDelivery(t, s, user, webhook)
+	if delivery.Status != webhookDelivered || delivery.Attempts != 1 || delivery.ResponseCode.Int32 != http.StatusOK {
+		t.Errorf("delivery is %s after %d attempts with status %v, want delivered after 1 with 200",
+			delivery.Status, delivery.Attempts, delivery.ResponseCode)
+	}
+}
+
+// queueTestDelivery queues a new post on feed for the feed's webhooks.
+func queueTestDelivery(t *testing.T, s *state, feed database.Feed) {
+	t.Helper()
+	ctx := context.Background()
+	webhooks, err := s.db.GetWebhooksForFeed(ctx, feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := createTestPosts(t, s, feed, "Hello")[0]
+	if err := queueWebhooks(ctx, s, webhooks, feed, post, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lastTestDelivery(t *testing.T, s *state, user database.User, webhook database.Webhook) database.GetWebhookDeliveriesForUserRow {
+	t.Helper()
+	deliveries, err := s.db.GetWebhookDeliveriesForUser(context.Background(), database.GetWebhookDeliveriesForUserParams{UserID: user.ID, WebhookID: webhook.ID, RowLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("webhook %d has %d deliveries, want 1", webhook.ID, len(deliveries))
+	}
+	return deliveries[0]
+}